@@ -0,0 +1,95 @@
+package common
+
+import "strings"
+
+// Features is a set of WebAssembly proposals on top of the 1.0 core.
+type Features uint64
+
+const (
+	// FeatureMVP is the 1.0 core, which is always enabled. It is set in
+	// every preset so that no preset is zero, which options take to mean
+	// their default.
+	FeatureMVP Features = 1 << iota
+	FeatureMutableGlobals
+	FeatureSignExtension
+	FeatureNonTrappingFloatToInt
+	FeatureMultiValue
+	FeatureBulkMemory
+	FeatureReferenceTypes
+	FeatureSIMD
+	FeatureThreads
+	FeatureTailCall
+	FeatureExceptionHandling
+	FeatureMemory64
+	FeatureMultiMemory
+	FeatureTypedFunctionReferences
+	FeatureGC
+	featureEnd
+)
+
+// Feature presets.
+const (
+	FeaturesMVP = FeatureMVP
+	// Features20 is what the 2.0 specification merged into the core.
+	Features20 = FeaturesMVP | FeatureMutableGlobals | FeatureSignExtension |
+		FeatureNonTrappingFloatToInt | FeatureMultiValue | FeatureBulkMemory |
+		FeatureReferenceTypes | FeatureSIMD
+	FeaturesAll = featureEnd - 1
+)
+
+// featureNames are the names the target_features section uses.
+var featureNames = map[Features]string{
+	FeatureMVP:                     "mvp",
+	FeatureMutableGlobals:          "mutable-globals",
+	FeatureSignExtension:           "sign-ext",
+	FeatureNonTrappingFloatToInt:   "nontrapping-fptoint",
+	FeatureMultiValue:              "multivalue",
+	FeatureBulkMemory:              "bulk-memory",
+	FeatureReferenceTypes:          "reference-types",
+	FeatureSIMD:                    "simd128",
+	FeatureThreads:                 "atomics",
+	FeatureTailCall:                "tail-call",
+	FeatureExceptionHandling:       "exception-handling",
+	FeatureMemory64:                "memory64",
+	FeatureMultiMemory:             "multimemory",
+	FeatureTypedFunctionReferences: "typed-function-references",
+	FeatureGC:                      "gc",
+}
+
+// Has reports whether every feature of f is in fs. The core is always
+// enabled, so Has(FeatureMVP) and Has(0) are always true.
+func (fs Features) Has(f Features) bool {
+	return (fs|FeatureMVP)&f == f
+}
+
+// String lists the names of the features in fs, e.g. "sign-ext|simd128".
+func (fs Features) String() string {
+	names := make([]string, 0, 4)
+	for f := FeatureMVP; f < featureEnd; f <<= 1 {
+		if fs&f != 0 {
+			names = append(names, featureNames[f])
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// featureAliases are further target_features names LLVM emits for
+// features this package does not split out.
+var featureAliases = map[string]Features{
+	"bulk-memory-opt":        FeatureBulkMemory,
+	"call-indirect-overlong": FeatureReferenceTypes,
+	"shared-mem":             FeatureThreads,
+}
+
+// FeatureByName returns the feature of a target_features name.
+func FeatureByName(name string) (Features, bool) {
+	if f, ok := featureAliases[name]; ok {
+		return f, true
+	}
+	for f, n := range featureNames {
+		if n == name {
+			return f, true
+		}
+	}
+	return 0, false
+}
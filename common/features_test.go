@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatures(t *testing.T) {
+	assert.True(t, Features(0).Has(FeatureMVP))
+	assert.True(t, FeaturesMVP.Has(0))
+	assert.False(t, FeaturesMVP.Has(FeatureSignExtension))
+	assert.True(t, Features20.Has(FeatureSIMD|FeatureBulkMemory))
+	assert.False(t, Features20.Has(FeatureSIMD|FeatureThreads))
+	assert.True(t, FeaturesAll.Has(Features20|FeatureGC))
+
+	assert.Equal(t, "sign-ext|simd128", (FeatureSignExtension | FeatureSIMD).String())
+	assert.Equal(t, "", Features(0).String())
+	for f := FeatureMVP; f < featureEnd; f <<= 1 {
+		byName, ok := FeatureByName(f.String())
+		assert.True(t, ok)
+		assert.Equal(t, f, byName)
+	}
+	byName, ok := FeatureByName("shared-mem")
+	assert.True(t, ok)
+	assert.Equal(t, FeatureThreads, byName)
+	_, ok = FeatureByName("unknown")
+	assert.False(t, ok)
+}
@@ -228,6 +228,9 @@ func DecodeInt33AsInt64(r *SliceBytes) (ret int64, num int, err error) {
 	var b int64
 	for shift < 35 {
 		b, err = r.ReadByteAsInt64()
+		if err != nil {
+			return 0, 0, fmt.Errorf("readByte failed: %w", err)
+		}
 		num++
 		ret |= (b & int33Mask2) << shift
 		shift += 7
@@ -245,7 +248,7 @@ func DecodeInt33AsInt64(r *SliceBytes) (ret int64, num int, err error) {
 	if ret&int33Mask5 > 0 {
 		ret = ret - int33Mask6
 	}
-	return ret, num, err
+	return ret, num, nil
 }
 
 func DecodeInt33AsInt64ByByte(r []byte) (ret int64, num int, err error) {
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzDecodeUint32(f *testing.F) {
+	f.Add([]byte{0x04})
+	f.Add([]byte{0x89, 0x80, 0x80, 0x80, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		actual, num, err := DecodeUint32(NewSliceBytes(data))
+		byByte, byByteNum, byByteErr := DecodeUint32ByByte(data)
+		if err != nil {
+			require.Error(t, byByteErr)
+			return
+		}
+		require.NoError(t, byByteErr)
+		assert.Equal(t, actual, byByte)
+		assert.Equal(t, num, byByteNum)
+
+		encoded := EncodeUint32(actual)
+		again, num, err := DecodeUint32(NewSliceBytes(encoded))
+		require.NoError(t, err)
+		assert.Equal(t, actual, again)
+		assert.Equal(t, len(encoded), num)
+	})
+}
+
+func FuzzDecodeUint64(f *testing.F) {
+	f.Add([]byte{0x04})
+	f.Add([]byte{0x89, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		actual, num, err := DecodeUint64(NewSliceBytes(data))
+		byByte, byByteNum, byByteErr := DecodeUint64ByByte(data)
+		if err != nil {
+			require.Error(t, byByteErr)
+			return
+		}
+		require.NoError(t, byByteErr)
+		assert.Equal(t, actual, byByte)
+		assert.Equal(t, num, byByteNum)
+
+		encoded := EncodeUint64(actual)
+		again, num, err := DecodeUint64(NewSliceBytes(encoded))
+		require.NoError(t, err)
+		assert.Equal(t, actual, again)
+		assert.Equal(t, uint64(len(encoded)), num)
+	})
+}
+
+func FuzzDecodeInt32(f *testing.F) {
+	f.Add([]byte{0x7f})
+	f.Add([]byte{0x80, 0x80, 0x80, 0x4f})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		actual, num, err := DecodeInt32(NewSliceBytes(data))
+		byByte, byByteNum, byByteErr := DecodeInt32ByByte(data)
+		if err != nil {
+			require.Error(t, byByteErr)
+			return
+		}
+		require.NoError(t, byByteErr)
+		assert.Equal(t, actual, byByte)
+		assert.Equal(t, num, byByteNum)
+
+		encoded := EncodeInt32(actual)
+		again, num, err := DecodeInt32(NewSliceBytes(encoded))
+		require.NoError(t, err)
+		assert.Equal(t, actual, again)
+		assert.Equal(t, len(encoded), num)
+	})
+}
+
+func FuzzDecodeInt33AsInt64(f *testing.F) {
+	f.Add([]byte{0x40})
+	f.Add([]byte{0xFF, 0x7e})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		actual, num, err := DecodeInt33AsInt64(NewSliceBytes(data))
+		byByte, byByteNum, byByteErr := DecodeInt33AsInt64ByByte(data)
+		if err != nil {
+			require.Error(t, byByteErr)
+			return
+		}
+		require.NoError(t, byByteErr)
+		assert.Equal(t, actual, byByte)
+		assert.Equal(t, num, byByteNum)
+		assert.GreaterOrEqual(t, actual, int64(-1)<<32)
+		assert.Less(t, actual, int64(1)<<32)
+
+		encoded := EncodeInt64(actual)
+		again, num, err := DecodeInt33AsInt64(NewSliceBytes(encoded))
+		require.NoError(t, err)
+		assert.Equal(t, actual, again)
+		assert.Equal(t, len(encoded), num)
+	})
+}
+
+func FuzzDecodeInt64(f *testing.F) {
+	f.Add([]byte{0x7f})
+	f.Add([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		actual, num, err := DecodeInt64(NewSliceBytes(data))
+		byByte, byByteNum, byByteErr := DecodeInt64ByByte(data)
+		if err != nil {
+			require.Error(t, byByteErr)
+			return
+		}
+		require.NoError(t, byByteErr)
+		assert.Equal(t, actual, byByte)
+		assert.Equal(t, num, byByteNum)
+
+		encoded := EncodeInt64(actual)
+		again, num, err := DecodeInt64(NewSliceBytes(encoded))
+		require.NoError(t, err)
+		assert.Equal(t, actual, again)
+		assert.Equal(t, len(encoded), num)
+	})
+}
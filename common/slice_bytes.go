@@ -2,13 +2,19 @@ package common
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
 )
 
+var (
+	errNegativeLength = errors.New("negative length")
+)
+
 type SliceBytes struct {
 	bs       []byte
 	pc       int
 	teeIndex int
+	base     int // offset of bs[0] in the enclosing input
 }
 
 func NewSliceBytes(bt []byte) *SliceBytes {
@@ -27,6 +33,14 @@ func (bt *SliceBytes) ReadByte() (byte, error) {
 	return bt.bs[bt.pc], nil
 }
 
+// PeekByte returns the next byte without reading it.
+func (bt *SliceBytes) PeekByte() (byte, error) {
+	if bt.pc+1 >= len(bt.bs) {
+		return 0, io.EOF
+	}
+	return bt.bs[bt.pc+1], nil
+}
+
 func (bt *SliceBytes) ReadByteAsInt32() (int32, error) {
 	b, err := bt.ReadByte()
 	return int32(b), err
@@ -38,6 +52,9 @@ func (bt *SliceBytes) ReadByteAsInt64() (int64, error) {
 }
 
 func (bt *SliceBytes) ReadByteN(n int) ([]byte, error) {
+	if n < 0 {
+		return []byte{}, errNegativeLength
+	}
 	if bt.pc+n >= len(bt.bs) {
 		return []byte{}, io.EOF
 	}
@@ -48,6 +65,20 @@ func (bt *SliceBytes) ReadByteN(n int) ([]byte, error) {
 	return res, nil
 }
 
+// ReadSlice consumes the next n bytes and returns them as a new SliceBytes
+// whose Offset stays relative to the enclosing input.
+func (bt *SliceBytes) ReadSlice(n int) (*SliceBytes, error) {
+	start := bt.Offset()
+	data, err := bt.ReadByteN(n)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := NewSliceBytes(data)
+	sub.base = start
+	return sub, nil
+}
+
 func (bt *SliceBytes) ReadUint32() (uint32, error) {
 	data, err := bt.ReadByteN(4)
 	if err != nil {
@@ -57,10 +88,31 @@ func (bt *SliceBytes) ReadUint32() (uint32, error) {
 	return binary.LittleEndian.Uint32(data), nil
 }
 
+func (bt *SliceBytes) ReadUint64() (uint64, error) {
+	data, err := bt.ReadByteN(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(data), nil
+}
+
 func (bt *SliceBytes) Remaining() int {
 	return len(bt.bs) - bt.pc - 1
 }
 
+// Bytes returns n bytes starting at offset, which is counted the same way
+// as Offset. The range must already have been read.
+func (bt *SliceBytes) Bytes(offset, n int) []byte {
+	return bt.bs[offset-bt.base : offset-bt.base+n]
+}
+
+// Offset returns the position of the next byte to be read, counted from
+// the start of the outermost input.
+func (bt *SliceBytes) Offset() int {
+	return bt.base + bt.pc + 1
+}
+
 func (bt *SliceBytes) ReadName() (string, int, error) {
 	// 读取name的长度
 	nameLen, nameLenSize, err := DecodeUint32(bt)
@@ -15,19 +15,67 @@ type FuncType struct {
 	ReturnTypes []ValType
 }
 
+// SubType is a type of the type section: a composite type, whether it may
+// have subtypes, and its declared supertypes. Types without the GC encodings
+// are final and have no supertypes.
+type SubType struct {
+	Final      bool
+	SuperTypes []TypeIdx
+	RecGroup   TypeIdx // index of the first type of its recursion group
+	Comp       CompType
+}
+
+// CompType is a function, struct or array type.
+type CompType struct {
+	Tag    byte        // TagFuncType, TagStructType or TagArrayType
+	Func   *FuncType   // TagFuncType only
+	Fields []FieldType // the fields of a struct, or the element of an array
+}
+
+// FieldType is the type of a struct field or array element.
+type FieldType struct {
+	Type    ValType // a value type or StorageTypeI8 or StorageTypeI16
+	Mutable bool
+}
+
+// Packed storage types of struct fields and array elements.
+const (
+	StorageTypeI8  ValType = 0x78 // i8
+	StorageTypeI16 ValType = 0x77 // i16
+)
+
+// Unpacked returns the value type a field of type st is read as.
+func (st ValType) Unpacked() ValType {
+	if st == StorageTypeI8 || st == StorageTypeI16 {
+		return ValTypeI32
+	}
+	return st
+}
+
 type TableType struct {
-	Tag       byte // 0x70
+	ElemType  ValType // a reference type
 	LimitsRef *Limits
+	Init      *Expr // table section only, nil unless given an init expression
 }
 
 type MemType struct {
 	LimitsRef *Limits
 }
 
+// TagType is the type of an exception tag: the parameters of its function
+// type are the values an exception carries, its results must be empty.
+type TagType struct {
+	Attribute byte // TagAttributeException, the only attribute
+	Type      TypeIdx
+}
+
+// TagAttributeException is the attribute of exception tags.
+const TagAttributeException byte = 0x00
+
 type Limits struct {
-	Tag byte
-	Min uint32
-	Max uint32
+	Tag byte   // LimitsFlag*
+	Min uint64 // u32 unless Is64
+	Max uint64 // u32 unless Is64
 }
 
 type GlobalType struct {
@@ -36,27 +84,173 @@ type GlobalType struct {
 }
 
 type Expr struct {
-	Data []byte
+	Data   []byte
+	Offset int // position of Data[0] in the module binary
 }
 
-type ValType byte
+// ValType is a value type. Number and vector types, and the nullable
+// references to abstract heap types, are their single byte encoding, e.g.
+// ValTypeFuncRef. Any other reference type is ValTypeRef or ValTypeRefNull
+// with its heap type in the bits above, see RefType.
+type ValType uint64
+
+// V128 is a 128-bit vector. Lo holds its first 8 bytes in little endian
+// order, the same layout it has in memory.
+type V128 struct {
+	Lo, Hi uint64
+}
 
 const (
 	ValTypeI32 ValType = 0x7F // i32
 	ValTypeI64 ValType = 0x7E // i64
 	ValTypeF32 ValType = 0x7D // f32
 	ValTypeF64 ValType = 0x7C // f64
+
+	ValTypeV128 ValType = 0x7B // v128
+
+	ValTypeFuncRef   ValType = 0x70 // funcref, (ref null func)
+	ValTypeExternRef ValType = 0x6F // externref, (ref null extern)
+	ValTypeExnRef    ValType = 0x69 // exnref, (ref null exn)
+
+	ValTypeAnyRef        ValType = 0x6E // anyref, (ref null any)
+	ValTypeEqRef         ValType = 0x6D // eqref, (ref null eq)
+	ValTypeI31Ref        ValType = 0x6C // i31ref, (ref null i31)
+	ValTypeStructRef     ValType = 0x6B // structref, (ref null struct)
+	ValTypeArrayRef      ValType = 0x6A // arrayref, (ref null array)
+	ValTypeNullRef       ValType = 0x71 // nullref, (ref null none)
+	ValTypeNullFuncRef   ValType = 0x73 // nullfuncref, (ref null nofunc)
+	ValTypeNullExternRef ValType = 0x72 // nullexternref, (ref null noextern)
+	ValTypeNullExnRef    ValType = 0x74 // nullexnref, (ref null noexn)
+
+	ValTypeRef     ValType = 0x64 // (ref ht)
+	ValTypeRefNull ValType = 0x63 // (ref null ht)
+)
+
+// HeapType is the heap type of a reference type as decoded from its s33
+// encoding: a type index when not negative, otherwise the sign-extended
+// single byte of an abstract heap type.
+type HeapType int64
+
+const (
+	HeapTypeFunc     HeapType = -0x10 // 0x70
+	HeapTypeExtern   HeapType = -0x11 // 0x6F
+	HeapTypeExn      HeapType = -0x17 // 0x69
+	HeapTypeAny      HeapType = -0x12 // 0x6E
+	HeapTypeEq       HeapType = -0x13 // 0x6D
+	HeapTypeI31      HeapType = -0x14 // 0x6C
+	HeapTypeStruct   HeapType = -0x15 // 0x6B
+	HeapTypeArray    HeapType = -0x16 // 0x6A
+	HeapTypeNone     HeapType = -0x0F // 0x71
+	HeapTypeNoFunc   HeapType = -0x0D // 0x73
+	HeapTypeNoExtern HeapType = -0x0E // 0x72
+	HeapTypeNoExn    HeapType = -0x0C // 0x74
+)
+
+// IsIndex reports whether ht refers to a defined type by index.
+func (ht HeapType) IsIndex() bool {
+	return ht >= 0
+}
+
+// IsValid reports whether ht is a type index or a known abstract heap type.
+func (ht HeapType) IsValid() bool {
+	return ht.IsIndex() || ht >= HeapTypeArray && ht <= HeapTypeNoExn || ht == HeapTypeExn
+}
+
+// RefType returns the reference type to ht. Nullable references to
+// abstract heap types are returned in their shorthand form, so
+// RefType(HeapTypeFunc, true) == ValTypeFuncRef.
+func RefType(ht HeapType, nullable bool) ValType {
+	if nullable && !ht.IsIndex() {
+		return ValType(ht + 0x80)
+	}
+	code := ValTypeRef
+	if nullable {
+		code = ValTypeRefNull
+	}
+	return code | ValType(ht+0x80)<<8
+}
+
+// IsValid reports whether vt is a known value type.
+func (vt ValType) IsValid() bool {
+	switch vt {
+	case ValTypeI32, ValTypeI64, ValTypeF32, ValTypeF64, ValTypeV128:
+		return true
+	}
+	return vt.IsRef() && vt.HeapType().IsValid()
+}
+
+// IsRef reports whether vt is a reference type.
+func (vt ValType) IsRef() bool {
+	if vt <= 0xFF {
+		ht := vt.HeapType()
+		return !ht.IsIndex() && ht.IsValid()
+	}
+	code := vt & 0xFF
+	return code == ValTypeRef || code == ValTypeRefNull
+}
+
+// HeapType returns the heap type of a reference type.
+func (vt ValType) HeapType() HeapType {
+	if vt <= 0xFF {
+		return HeapType(vt) - 0x80
+	}
+	return HeapType(vt>>8) - 0x80
+}
+
+// Nullable reports whether a reference type includes null.
+func (vt ValType) Nullable() bool {
+	return vt <= 0xFF || vt&0xFF == ValTypeRefNull
+}
+
+// Defaultable reports whether vt has a default value, which locals must
+// have unless they are set before use. Only non-nullable references do not.
+func (vt ValType) Defaultable() bool {
+	return !vt.IsRef() || vt.Nullable()
+}
+
+// Tags of the type section entries.
+const (
+	TagFuncType     byte = 0x60
+	TagStructType   byte = 0x5F
+	TagArrayType    byte = 0x5E
+	TagSubType      byte = 0x50 // sub x* comptype, not final
+	TagSubFinalType byte = 0x4F // sub final x* comptype
+	TagRecType      byte = 0x4E // rec subtype*
 )
 
+// Limits flags are a bit set of limitsFlagBit*. Shared memories must have
+// a max.
 const (
-	TagFuncType byte = 0x60
+	LimitsFlagNoMax           byte = 0x00
+	LimitsFlagHasMax          byte = 0x01
+	LimitsFlagSharedHasMax    byte = 0x03
+	LimitsFlagI64NoMax        byte = 0x04
+	LimitsFlagI64HasMax       byte = 0x05
+	LimitsFlagI64SharedHasMax byte = 0x07
 )
 
 const (
-	LimitsFlagNoMax  byte = 0x00
-	LimitsFlagHasMax byte = 0x01
+	limitsFlagBitHasMax byte = 0x01
+	limitsFlagBitShared byte = 0x02
+	limitsFlagBitI64    byte = 0x04
 )
 
+// HasMax reports whether Max is set.
+func (l *Limits) HasMax() bool {
+	return l.Tag&limitsFlagBitHasMax != 0
+}
+
+// Shared reports whether the limits belong to a shared memory.
+func (l *Limits) Shared() bool {
+	return l.Tag&limitsFlagBitShared != 0
+}
+
+// Is64 reports whether the limits belong to a memory64 memory, addressed
+// with i64 values.
+func (l *Limits) Is64() bool {
+	return l.Tag&limitsFlagBitI64 != 0
+}
+
 const (
 	NotMutable byte = 0x00
 	Mutable    byte = 0x01
@@ -65,3 +259,36 @@ const (
 const (
 	ExprEnd byte = 0x0B
 )
+
+// BlockType is the type of a block, loop or if as decoded from its s33
+// encoding: a type index when not negative, otherwise the sign-extended
+// single byte of an empty block type or a single result value type.
+type BlockType int64
+
+const (
+	BlockTypeEmpty BlockType = -0x40 // 0x40
+)
+
+// BlockTypeOf returns the block type with a single result of type vt. A
+// reference type that does not fit in a single byte becomes -vt, which is
+// below the range of the single byte ones.
+func BlockTypeOf(vt ValType) BlockType {
+	if vt > 0xFF {
+		return -BlockType(vt)
+	}
+	return BlockType(int64(vt) - 0x80)
+}
+
+// IsIndex reports whether bt refers to a function type by index.
+func (bt BlockType) IsIndex() bool {
+	return bt >= 0
+}
+
+// ValType returns the result type of a block type that is neither empty nor
+// an index.
+func (bt BlockType) ValType() ValType {
+	if bt < -0x80 {
+		return ValType(-bt)
+	}
+	return ValType(bt + 0x80)
+}
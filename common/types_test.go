@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefType(t *testing.T) {
+	assert.Equal(t, ValTypeFuncRef, RefType(HeapTypeFunc, true))
+	assert.Equal(t, ValTypeExnRef, RefType(HeapTypeExn, true))
+
+	for _, ht := range []HeapType{HeapTypeFunc, HeapTypeExtern, 0, 7, 1<<32 - 1} {
+		for _, nullable := range []bool{false, true} {
+			vt := RefType(ht, nullable)
+			assert.True(t, vt.IsValid())
+			assert.True(t, vt.IsRef())
+			assert.Equal(t, ht, vt.HeapType())
+			assert.Equal(t, nullable, vt.Nullable())
+			assert.Equal(t, nullable, vt.Defaultable())
+			assert.Equal(t, vt, BlockTypeOf(vt).ValType())
+			assert.False(t, BlockTypeOf(vt).IsIndex())
+		}
+	}
+
+	assert.True(t, ValTypeI32.Defaultable())
+	assert.False(t, ValTypeI32.IsRef())
+	assert.False(t, ValTypeRef.IsValid())
+	assert.False(t, RefType(-0x20, false).IsValid())
+	assert.Equal(t, ValTypeI64, BlockTypeOf(ValTypeI64).ValType())
+}
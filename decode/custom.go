@@ -0,0 +1,66 @@
+package decode
+
+import "fmt"
+
+// CustomSectionDecoder decodes the payload of a custom section, after its
+// name, into a value made available as CustomSec.Value.
+type CustomSectionDecoder func(name string, data []byte) (any, error)
+
+// CustomSectionRegistry maps custom section names to their decoders.
+type CustomSectionRegistry struct {
+	decoders map[string]CustomSectionDecoder
+}
+
+// NewCustomSectionRegistry returns a registry holding the decoders for the
+// sections this package understands: name, producers and target_features.
+func NewCustomSectionRegistry() *CustomSectionRegistry {
+	r := &CustomSectionRegistry{decoders: map[string]CustomSectionDecoder{}}
+	r.Register(NameSectionName, func(_ string, data []byte) (any, error) {
+		return decodeNameSection(data)
+	})
+	r.Register(ProducersSectionName, func(_ string, data []byte) (any, error) {
+		return decodeProducersSection(data)
+	})
+	r.Register(TargetFeaturesSectionName, func(_ string, data []byte) (any, error) {
+		return decodeTargetFeaturesSection(data)
+	})
+	return r
+}
+
+// Register sets the decoder for a section name, replacing any previous one.
+// A nil decoder leaves sections of that name undecoded.
+func (r *CustomSectionRegistry) Register(name string, decoder CustomSectionDecoder) {
+	if decoder == nil {
+		delete(r.decoders, name)
+		return
+	}
+	r.decoders[name] = decoder
+}
+
+func (r *CustomSectionRegistry) Lookup(name string) (CustomSectionDecoder, bool) {
+	decoder, ok := r.decoders[name]
+	return decoder, ok
+}
+
+// decodeCustomSection runs decoder on a section, turning a panic into an
+// error so that a faulty decoder cannot make DecodeModule panic.
+func decodeCustomSection(decoder CustomSectionDecoder, name string, data []byte) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, fmt.Errorf("custom section %q decoder panicked: %v", name, r)
+		}
+	}()
+	return decoder(name, data)
+}
+
+var builtinCustomSections = NewCustomSectionRegistry()
+
+// GetCustomSection returns the first custom section with the given name, or nil.
+func (module *Module) GetCustomSection(name string) *CustomSec {
+	for i := range module.CustomSecs {
+		if module.CustomSecs[i].Name == name {
+			return &module.CustomSecs[i]
+		}
+	}
+	return nil
+}
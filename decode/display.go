@@ -2,11 +2,87 @@ package decode
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/opcode"
 )
 
-func (module *Module) display() string {
+var sectionNames = [...]string{
+	SecCustomID:    "Custom",
+	SecTypeID:      "Type",
+	SecImportID:    "Import",
+	SecFuncID:      "Function",
+	SecTableID:     "Table",
+	SecMemID:       "Memory",
+	SecGlobalID:    "Global",
+	SecExportID:    "Export",
+	SecStartID:     "Start",
+	SecElemID:      "Elem",
+	SecCodeID:      "Code",
+	SecDataID:      "Data",
+	SecDataCountID: "DataCount",
+	SecTagID:       "Tag",
+}
+
+// SectionName returns the name of a section id as used by objdump output.
+func SectionName(id byte) string {
+	if int(id) < len(sectionNames) {
+		return sectionNames[id]
+	}
+	return fmt.Sprintf("<unknown %d>", id)
+}
+
+// DisplayHeaders lists every section with its offsets and size.
+func (module *Module) DisplayHeaders() string {
+	str := "Sections:\n"
+	for _, sec := range module.Sections {
+		str += fmt.Sprintf("%9s start=0x%08x end=0x%08x (size=0x%08x)",
+			SectionName(sec.ID), sec.Offset, sec.Offset+sec.Size, sec.Size)
+		if sec.ID == SecCustomID {
+			str += fmt.Sprintf(" %q", sec.Name)
+		} else if count, ok := module.sectionCount(sec.ID); ok {
+			str += fmt.Sprintf(" count: %d", count)
+		}
+		str += "\n"
+	}
+	return str
+}
+
+func (module *Module) sectionCount(id byte) (int, bool) {
+	switch id {
+	case SecTypeID:
+		return len(module.TypeSec), true
+	case SecImportID:
+		return len(module.ImportSec), true
+	case SecFuncID:
+		return len(module.FuncSec), true
+	case SecTableID:
+		return len(module.TableSec), true
+	case SecMemID:
+		return len(module.MemSec), true
+	case SecGlobalID:
+		return len(module.GlobalSec), true
+	case SecExportID:
+		return len(module.ExportSec), true
+	case SecElemID:
+		return len(module.ElemSec), true
+	case SecCodeID:
+		return len(module.CodeSec), true
+	case SecDataID:
+		return len(module.DataSec), true
+	case SecDataCountID:
+		return int(*module.DataCountSec), true
+	case SecTagID:
+		return len(module.TagSec), true
+	default:
+		return 0, false
+	}
+}
+
+// DisplayDetails prints the contents of every known section.
+func (module *Module) DisplayDetails() string {
 	str := ""
 	// Magic
 	str += fmt.Sprintf("Magic: %d\n", module.Magic)
@@ -22,6 +98,8 @@ func (module *Module) display() string {
 	str += module.displayTableSec()
 	// MemSec
 	str += module.displayMemorySec()
+	// TagSec
+	str += module.displayTagSec()
 	// GlobalSec
 	str += module.displayGlobalSec()
 	// ExportSec
@@ -34,29 +112,81 @@ func (module *Module) display() string {
 	str += module.displayCodeSec()
 	// DataSec
 	str += module.displayDataSec()
+	// name custom section
+	str += module.displayNameSec()
+	// producers and target_features custom sections
+	str += module.displayProducersSec()
+	str += module.displayTargetFeaturesSec()
 	return str
 }
 
 func (module *Module) displayTypeSec() string {
-	displayValType := func(valTypes []common.ValType) string {
-		str := "("
-		for i, valType := range valTypes {
-			if i > 0 {
-				str += ", "
-			}
-			str += displayValType(valType)
-		}
-		str += ")"
-		return str
-	}
 	str := ""
 	str += fmt.Sprintf("Type[%d]:\n", len(module.TypeSec))
 	for i, t := range module.TypeSec {
-		str += fmt.Sprintf("  type[%d]: %s->%s\n", i, displayValType(t.InputTypes), displayValType(t.ReturnTypes))
+		str += fmt.Sprintf("  type[%d]: %s", i, displayCompType(&t.Comp))
+		if !t.Final {
+			str += " open"
+		}
+		for _, super := range t.SuperTypes {
+			str += fmt.Sprintf(" <: %d", super)
+		}
+		if int(t.RecGroup) != i || i+1 < len(module.TypeSec) && module.TypeSec[i+1].RecGroup == t.RecGroup {
+			str += fmt.Sprintf(" rec=%d", t.RecGroup)
+		}
+		str += "\n"
+	}
+	return str
+}
+
+func displayCompType(comp *common.CompType) string {
+	switch comp.Tag {
+	case common.TagFuncType:
+		return displayValTypes(comp.Func.InputTypes) + "->" + displayValTypes(comp.Func.ReturnTypes)
+	case common.TagStructType:
+		return "struct" + displayFieldTypes(comp.Fields)
+	default:
+		return "array" + displayFieldTypes(comp.Fields)
+	}
+}
+
+func displayValTypes(valTypes []common.ValType) string {
+	str := "("
+	for i, valType := range valTypes {
+		if i > 0 {
+			str += ", "
+		}
+		str += displayValType(valType)
+	}
+	str += ")"
+	return str
+}
+
+func displayFieldTypes(fields []common.FieldType) string {
+	str := "{"
+	for i, field := range fields {
+		if i > 0 {
+			str += ", "
+		}
+		if field.Mutable {
+			str += "mut "
+		}
+		str += displayStorageType(field.Type)
 	}
+	str += "}"
 	return str
 }
 
+func displayStorageType(st common.ValType) string {
+	switch st {
+	case common.StorageTypeI8:
+		return "i8"
+	case common.StorageTypeI16:
+		return "i16"
+	}
+	return displayValType(st)
+}
+
 func (module *Module) displayImportSec() string {
 	str := ""
 	str += fmt.Sprintf("Import[%d]:\n", len(module.ImportSec))
@@ -71,8 +201,10 @@ func (module *Module) displayImportSec() string {
 			return fmt.Sprintf("  memory[%d]: %s.%s, %s\n", idx, imp.Module, imp.Name, displayLimits(imp.Desc.Mem.LimitsRef))
 		case ImportTagGlobal:
 			return fmt.Sprintf("  global[%d]: %s.%s, %s\n", idx, imp.Module, imp.Name, displayGlobalType(imp.Desc.Global))
+		case ImportTagTag:
+			return fmt.Sprintf("  tag[%d]: %s.%s, sig=%d\n", idx, imp.Module, imp.Name, imp.Desc.ExnTag.Type)
 		default:
-			panic("unknown import tag")
+			return fmt.Sprintf("  <unknown import tag %d>[%d]: %s.%s\n", imp.Desc.Tag, idx, imp.Module, imp.Name)
 		}
 	}
 
@@ -92,13 +224,85 @@ func displayValType(valType common.ValType) string {
 		return "f32"
 	case common.ValTypeF64:
 		return "f64"
-	default:
-		panic(fmt.Sprintf("<unknown value_type %d>", int8(valType)))
+	case common.ValTypeV128:
+		return "v128"
+	case common.ValTypeFuncRef:
+		return "funcref"
+	case common.ValTypeExternRef:
+		return "externref"
+	case common.ValTypeExnRef:
+		return "exnref"
+	case common.ValTypeAnyRef:
+		return "anyref"
+	case common.ValTypeEqRef:
+		return "eqref"
+	case common.ValTypeI31Ref:
+		return "i31ref"
+	case common.ValTypeStructRef:
+		return "structref"
+	case common.ValTypeArrayRef:
+		return "arrayref"
+	case common.ValTypeNullRef:
+		return "nullref"
+	case common.ValTypeNullFuncRef:
+		return "nullfuncref"
+	case common.ValTypeNullExternRef:
+		return "nullexternref"
+	case common.ValTypeNullExnRef:
+		return "nullexnref"
+	}
+	if !valType.IsRef() {
+		return fmt.Sprintf("<unknown value_type 0x%02x>", uint64(valType))
+	}
+	if valType.Nullable() {
+		return fmt.Sprintf("(ref null %s)", displayHeapType(valType.HeapType()))
 	}
+	return fmt.Sprintf("(ref %s)", displayHeapType(valType.HeapType()))
+}
+
+// displayHeapType is a heap type as used by ref.null and typed references.
+func displayHeapType(ht common.HeapType) string {
+	switch ht {
+	case common.HeapTypeFunc:
+		return "func"
+	case common.HeapTypeExtern:
+		return "extern"
+	case common.HeapTypeExn:
+		return "exn"
+	case common.HeapTypeAny:
+		return "any"
+	case common.HeapTypeEq:
+		return "eq"
+	case common.HeapTypeI31:
+		return "i31"
+	case common.HeapTypeStruct:
+		return "struct"
+	case common.HeapTypeArray:
+		return "array"
+	case common.HeapTypeNone:
+		return "none"
+	case common.HeapTypeNoFunc:
+		return "nofunc"
+	case common.HeapTypeNoExtern:
+		return "noextern"
+	case common.HeapTypeNoExn:
+		return "noexn"
+	}
+	if ht.IsIndex() {
+		return fmt.Sprintf("%d", ht)
+	}
+	return fmt.Sprintf("<unknown heap_type %d>", ht)
 }
 
 func displayLimits(limit *common.Limits) string {
-	return fmt.Sprintf("{min: %d, max: %d}", limit.Min, limit.Max)
+	str := fmt.Sprintf("{min: %d, max: %d", limit.Min, limit.Max)
+	if limit.Is64() {
+		str += ", i64"
+	}
+	if limit.Shared() {
+		str += ", shared"
+	}
+	return str + "}"
 }
 
 func displayGlobal(global *Global) string {
@@ -110,7 +314,15 @@ func displayGlobalType(globalType *common.GlobalType) string {
 }
 
 func displayExpr(expr *common.Expr) string {
-	return fmt.Sprintf("%v", expr.Data)
+	instrs, err := DecodeInstructions(expr)
+	if err != nil {
+		return fmt.Sprintf("%v", expr.Data)
+	}
+	strs := make([]string, 0, len(instrs))
+	for _, instr := range instrs {
+		strs = append(strs, instr.String())
+	}
+	return "[" + strings.Join(strs, ", ") + "]"
 }
 
 func (module *Module) displayFuncSec() string {
@@ -126,7 +338,7 @@ func (module *Module) displayTableSec() string {
 	str := ""
 	str += fmt.Sprintf("Table[%d]:\n", len(module.TableSec))
 	for i, table := range module.TableSec {
-		str += fmt.Sprintf("  table[%d]: %s\n", i, displayLimits(table.LimitsRef))
+		str += fmt.Sprintf("  table[%d]: %s %s\n", i, displayValType(table.ElemType), displayLimits(table.LimitsRef))
 	}
 	return str
 }
@@ -140,6 +352,15 @@ func (module *Module) displayMemorySec() string {
 	return str
 }
 
+func (module *Module) displayTagSec() string {
+	str := ""
+	str += fmt.Sprintf("Tag[%d]:\n", len(module.TagSec))
+	for i, tag := range module.TagSec {
+		str += fmt.Sprintf("  tag[%d]: sig=%d\n", i, tag.Type)
+	}
+	return str
+}
+
 func (module *Module) displayGlobalSec() string {
 	str := ""
 	str += fmt.Sprintf("Global[%d]:\n", len(module.GlobalSec))
@@ -168,8 +389,10 @@ func displayExportDesc(export *Export) string {
 		return fmt.Sprintf("memory[%d]=%s", export.Desc.Idx, export.Name)
 	case ExportTagGlobal:
 		return fmt.Sprintf("global[%d]=%s", export.Desc.Idx, export.Name)
+	case ExportTagTag:
+		return fmt.Sprintf("tag[%d]=%s", export.Desc.Idx, export.Name)
 	default:
-		panic("unknown export tag")
+		return fmt.Sprintf("<unknown export tag %d>[%d]=%s", export.Desc.Tag, export.Desc.Idx, export.Name)
 	}
 }
 
@@ -189,7 +412,26 @@ func (module *Module) displayElementSec() string {
 }
 
 func displayElement(elem *Elem) string {
-	str := fmt.Sprintf("table=%d, offset=%s, init=[", elem.Table, displayExpr(elem.Offset))
+	str := ""
+	switch elem.Mode {
+	case SegmentModeActive:
+		str += fmt.Sprintf("table=%d, offset=%s, ", elem.Table, displayExpr(elem.Offset))
+	case SegmentModePassive:
+		str += "passive, "
+	case SegmentModeDeclarative:
+		str += "declarative, "
+	}
+	if elem.Exprs != nil {
+		str += fmt.Sprintf("type=%s, init=[", displayValType(elem.Type))
+		for i, expr := range elem.Exprs {
+			if i > 0 {
+				str += ", "
+			}
+			str += displayExpr(expr)
+		}
+		return str + "]"
+	}
+	str += "init=["
 	for i, init := range elem.Init {
 		if i > 0 {
 			str += ", "
@@ -204,7 +446,12 @@ func (module *Module) displayCodeSec() string {
 	str := ""
 	str += fmt.Sprintf("Code[%d]:\n", len(module.CodeSec))
 
-	for i, code := range module.CodeSec {
+	for i := range module.CodeSec {
+		code, err := module.DecodeCode(i)
+		if err != nil {
+			str += fmt.Sprintf("  code[%d]: %v\n", i, err)
+			continue
+		}
 		str += fmt.Sprintf("  code[%d]: %s\n", i, displayCode(code))
 	}
 
@@ -222,7 +469,6 @@ func displayCode(code *Code) string {
 	}
 
 	str += fmt.Sprintf("\nbody: %s", displayExpr(code.Expr))
-	fmt.Println(str)
 
 	return str
 }
@@ -238,5 +484,175 @@ func (module *Module) displayDataSec() string {
 }
 
 func displayData(data *Data) string {
+	if data.Mode == SegmentModePassive {
+		return fmt.Sprintf("passive, init=%v", data.Init)
+	}
 	return fmt.Sprintf("mem=%d, offset=%s, init=%v", data.Mem, displayExpr(data.Offset), data.Init)
 }
+
+// DisplayDisassembly prints the instructions of every function body along
+// with their byte offsets, and their source lines if the module carries
+// DWARF line info.
+func (module *Module) DisplayDisassembly() (string, error) {
+	importedFuncs := module.ImportedFuncCount()
+	debug, _ := module.DebugInfo()
+
+	str := "Code Disassembly:\n"
+	for i, code := range module.CodeSec {
+		funcIdx := common.FuncIdx(importedFuncs + i)
+		str += fmt.Sprintf("\n%06x func[%d]%s:\n", code.Offset, funcIdx, module.displayFuncName(funcIdx))
+		if _, err := module.DecodeCode(i); err != nil {
+			return str, err
+		}
+		body, err := module.disassemble(code, funcIdx, debug)
+		str += body
+		if err != nil {
+			return str, err
+		}
+	}
+	return str, nil
+}
+
+func (module *Module) disassemble(code *Code, funcIdx common.FuncIdx, debug *DebugInfo) (string, error) {
+	str := ""
+	localIdx := uint64(0)
+	if funcType, err := module.GetFuncType(funcIdx); err == nil {
+		localIdx = uint64(len(funcType.InputTypes))
+	}
+	for _, loc := range code.Locals {
+		str += fmt.Sprintf(" %06x: %-28s| local[%d..%d] type=%s\n", code.Offset, "",
+			localIdx, localIdx+uint64(loc.N)-1, displayValType(loc.Type))
+		localIdx += uint64(loc.N)
+	}
+
+	instrs, err := DecodeInstructions(code.Expr)
+	depth := 1
+	var lastLoc SourceLocation
+	for i, instr := range instrs {
+		if debug != nil {
+			if loc, ok := debug.LookupSource(module, instr.Offset); ok && loc != lastLoc {
+				str += fmt.Sprintf(" ; %s\n", loc)
+				lastLoc = loc
+			}
+		}
+
+		end := code.Expr.Offset + len(code.Expr.Data)
+		if i+1 < len(instrs) {
+			end = instrs[i+1].Offset
+		}
+		raw := code.Expr.Data[instr.Offset-code.Expr.Offset : end-code.Expr.Offset]
+
+		indent := depth
+		switch instr.Opcode {
+		case opcode.End_:
+			depth--
+			indent = depth
+		case opcode.Else_:
+			indent = depth - 1
+		case opcode.Block, opcode.Loop, opcode.If, opcode.TryTable:
+			depth++
+		}
+		str += fmt.Sprintf(" %06x: %-28s| %s%s%s\n", instr.Offset, displayRaw(raw),
+			strings.Repeat(" ", max(indent, 0)), instr, module.displayInstrNames(instr, funcIdx))
+	}
+	return str, err
+}
+
+func displayRaw(raw []byte) string {
+	const maxBytes = 9
+	str := ""
+	for i, b := range raw {
+		if i == maxBytes {
+			return str + "..."
+		}
+		str += fmt.Sprintf("%02x ", b)
+	}
+	return str
+}
+
+func (module *Module) displayFuncName(idx common.FuncIdx) string {
+	if name := module.GetFuncName(idx); name != "" {
+		return " <" + name + ">"
+	}
+	return ""
+}
+
+// displayInstrNames annotates an instruction's index immediate with the name
+// the name section gives it.
+func (module *Module) displayInstrNames(instr Instruction, funcIdx common.FuncIdx) string {
+	switch instr.Opcode {
+	case opcode.Call, opcode.ReturnCall:
+		return module.displayFuncName(instr.Index)
+	case opcode.LocalGet, opcode.LocalSet, opcode.LocalTee:
+		if name := module.GetLocalName(funcIdx, instr.Index); name != "" {
+			return " <" + name + ">"
+		}
+	case opcode.GlobalGet, opcode.GlobalSet:
+		if module.Names != nil && module.Names.GlobalNames[instr.Index] != "" {
+			return " <" + module.Names.GlobalNames[instr.Index] + ">"
+		}
+	}
+	return ""
+}
+
+func (module *Module) displayNameSec() string {
+	if module.Names == nil {
+		return ""
+	}
+	str := "Name:\n"
+	if module.Names.ModuleName != "" {
+		str += fmt.Sprintf("  module: %q\n", module.Names.ModuleName)
+	}
+	for _, idx := range sortedKeys(module.Names.FuncNames) {
+		str += fmt.Sprintf("  func[%d]: %s\n", idx, module.Names.FuncNames[idx])
+		locals := module.Names.LocalNames[idx]
+		for _, localIdx := range sortedKeys(locals) {
+			str += fmt.Sprintf("    local[%d]: %s\n", localIdx, locals[localIdx])
+		}
+	}
+	for _, idx := range sortedKeys(module.Names.GlobalNames) {
+		str += fmt.Sprintf("  global[%d]: %s\n", idx, module.Names.GlobalNames[idx])
+	}
+	for _, idx := range sortedKeys(module.Names.DataNames) {
+		str += fmt.Sprintf("  data[%d]: %s\n", idx, module.Names.DataNames[idx])
+	}
+	return str
+}
+
+func sortedKeys(m NameMap) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (module *Module) displayProducersSec() string {
+	if module.Producers == nil {
+		return ""
+	}
+	displayValues := func(field string, values []ProducerValue) string {
+		str := ""
+		for _, value := range values {
+			str += fmt.Sprintf("  %s: %s %s\n", field, value.Name, value.Version)
+		}
+		return str
+	}
+	str := "Producers:\n"
+	str += displayValues(ProducersFieldLanguage, module.Producers.Language)
+	str += displayValues(ProducersFieldProcessedBy, module.Producers.ProcessedBy)
+	str += displayValues(ProducersFieldSDK, module.Producers.SDK)
+	return str
+}
+
+func (module *Module) displayTargetFeaturesSec() string {
+	if module.TargetFeatures == nil {
+		return ""
+	}
+	str := fmt.Sprintf("TargetFeatures[%d]:\n", len(module.TargetFeatures.Features))
+	for _, feature := range module.TargetFeatures.Features {
+		str += fmt.Sprintf("  %c %s\n", feature.Prefix, feature.Name)
+	}
+	return str
+}
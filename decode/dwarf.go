@@ -0,0 +1,188 @@
+package decode
+
+import (
+	"debug/dwarf"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var ErrNoDebugInfo = errors.New("module has no DWARF debug info")
+
+// SourceLocation is where an instruction came from in the guest's sources.
+type SourceLocation struct {
+	File     string
+	Line     int
+	Column   int
+	Function string // "" when no subprogram covers the address
+}
+
+func (loc SourceLocation) String() string {
+	str := fmt.Sprintf("%s:%d", loc.File, loc.Line)
+	if loc.Column != 0 {
+		str += fmt.Sprintf(":%d", loc.Column)
+	}
+	if loc.Function != "" {
+		str += " (" + loc.Function + ")"
+	}
+	return str
+}
+
+// DebugInfo is the DWARF debug info of a module. As with every wasm DWARF
+// producer, addresses are offsets into the code section content.
+type DebugInfo struct {
+	Data  *dwarf.Data
+	rows  []lineRow  // sorted by address
+	funcs []funcSpan // sorted by low address
+}
+
+type lineRow struct {
+	address uint64
+	file    string
+	line    int
+	column  int
+	end     bool // first address after a sequence
+}
+
+type funcSpan struct {
+	low, high uint64
+	name      string
+}
+
+// DebugInfo loads the DWARF sections carried in `.debug_*` custom sections.
+// It returns ErrNoDebugInfo if there is no `.debug_info` section.
+func (module *Module) DebugInfo() (*DebugInfo, error) {
+	sections := map[string][]byte{}
+	for _, sec := range module.CustomSecs {
+		sections[sec.Name] = sec.Bytes
+	}
+	if sections[".debug_info"] == nil {
+		return nil, ErrNoDebugInfo
+	}
+
+	data, err := dwarf.New(sections[".debug_abbrev"], sections[".debug_aranges"], sections[".debug_frame"],
+		sections[".debug_info"], sections[".debug_line"], sections[".debug_pubnames"],
+		sections[".debug_ranges"], sections[".debug_str"])
+	if err != nil {
+		return nil, err
+	}
+	// DWARF 5 moved parts of the info into sections dwarf.New does not take
+	for _, name := range []string{".debug_addr", ".debug_line_str", ".debug_loclists", ".debug_rnglists", ".debug_str_offsets"} {
+		if sections[name] == nil {
+			continue
+		}
+		if err := data.AddSection(name, sections[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	debug := &DebugInfo{Data: data}
+	if err := debug.load(); err != nil {
+		return nil, err
+	}
+	return debug, nil
+}
+
+func (debug *DebugInfo) load() error {
+	reader := debug.Data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			if err := debug.loadLines(entry); err != nil {
+				return err
+			}
+		case dwarf.TagSubprogram:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			ranges, err := debug.Data.Ranges(entry)
+			if err != nil {
+				return err
+			}
+			for _, r := range ranges {
+				debug.funcs = append(debug.funcs, funcSpan{low: r[0], high: r[1], name: name})
+			}
+		}
+	}
+
+	sort.SliceStable(debug.rows, func(i, j int) bool {
+		return debug.rows[i].address < debug.rows[j].address
+	})
+	sort.SliceStable(debug.funcs, func(i, j int) bool {
+		return debug.funcs[i].low < debug.funcs[j].low
+	})
+	return nil
+}
+
+func (debug *DebugInfo) loadLines(cu *dwarf.Entry) error {
+	lr, err := debug.Data.LineReader(cu)
+	if err != nil || lr == nil {
+		return err
+	}
+
+	var le dwarf.LineEntry
+	for {
+		if err := lr.Next(&le); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		row := lineRow{address: le.Address, line: le.Line, column: le.Column, end: le.EndSequence}
+		if le.File != nil {
+			row.file = le.File.Name
+		}
+		debug.rows = append(debug.rows, row)
+	}
+}
+
+// Lookup maps a code-section-relative address to its source location.
+func (debug *DebugInfo) Lookup(address uint64) (SourceLocation, bool) {
+	// the last row at or before the address describes it, unless that row
+	// closes its sequence
+	i := sort.Search(len(debug.rows), func(i int) bool {
+		return debug.rows[i].address > address
+	}) - 1
+	if i < 0 || debug.rows[i].end {
+		return SourceLocation{}, false
+	}
+
+	row := debug.rows[i]
+	loc := SourceLocation{File: row.file, Line: row.line, Column: row.column}
+	for _, fn := range debug.funcs {
+		if fn.low > address {
+			break
+		}
+		if address < fn.high {
+			loc.Function = fn.name
+		}
+	}
+	return loc, true
+}
+
+// CodeAddress converts an offset in the module binary, such as
+// Instruction.Offset, into the code-section-relative address DWARF uses.
+func (module *Module) CodeAddress(offset int) (uint64, bool) {
+	for _, sec := range module.Sections {
+		if sec.ID == SecCodeID && offset >= sec.Offset && offset < sec.Offset+sec.Size {
+			return uint64(offset - sec.Offset), true
+		}
+	}
+	return 0, false
+}
+
+// LookupSource maps an offset in the module binary to its source location.
+func (debug *DebugInfo) LookupSource(module *Module, offset int) (SourceLocation, bool) {
+	address, ok := module.CodeAddress(offset)
+	if !ok {
+		return SourceLocation{}, false
+	}
+	return debug.Lookup(address)
+}
@@ -0,0 +1,111 @@
+package decode
+
+import (
+	"testing"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dwarfModule is a module with one function `nop nop end` and hand written
+// DWARF 4 info mapping its instructions to a.c lines 10 and 11, with a
+// subprogram `add` covering only the first nop.
+func dwarfModule() []byte {
+	abbrev := []byte{
+		0x01, 0x11, 0x01, // 1: compile_unit, has children
+		0x03, 0x08, // name: string
+		0x10, 0x17, // stmt_list: sec_offset
+		0x11, 0x01, // low_pc: addr
+		0x12, 0x06, // high_pc: data4
+		0x1b, 0x08, // comp_dir: string
+		0x00, 0x00,
+		0x02, 0x2e, 0x00, // 2: subprogram, no children
+		0x03, 0x08, // name: string
+		0x11, 0x01, // low_pc: addr
+		0x12, 0x06, // high_pc: data4
+		0x00, 0x00,
+		0x00,
+	}
+	info := []byte{
+		0x00, 0x00, 0x00, 0x00, // unit_length, patched below
+		0x04, 0x00, // version
+		0x00, 0x00, 0x00, 0x00, // debug_abbrev_offset
+		0x04, // address_size
+		0x01, 'a', '.', 'c', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, '/', 's', 'r', 'c', 0x00,
+		0x02, 'a', 'd', 'd', 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		0x00,
+	}
+	info[0] = byte(len(info) - 4)
+	lineHeader := []byte{
+		0x01, 0x01, 0x01, 0xfb, 0x0e, 0x0d, // min_inst_length .. opcode_base
+		0x00, 0x01, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, // standard_opcode_lengths
+		0x00,                                  // include_directories
+		'a', '.', 'c', 0x00, 0x00, 0x00, 0x00, // file_names
+		0x00,
+	}
+	program := []byte{
+		0x00, 0x05, 0x02, 0x03, 0x00, 0x00, 0x00, // set_address 3
+		0x03, 0x09, // advance_line 9 (line 10)
+		0x05, 0x03, // set_column 3
+		0x01,       // copy
+		0x02, 0x01, // advance_pc 1
+		0x03, 0x01, // advance_line 1
+		0x01,       // copy
+		0x02, 0x02, // advance_pc 2
+		0x00, 0x01, 0x01, // end_sequence
+	}
+	line := []byte{0x00, 0x00, 0x00, 0x00, 0x04, 0x00, byte(len(lineHeader)), 0x00, 0x00, 0x00}
+	line = append(append(line, lineHeader...), program...)
+	line[0] = byte(len(line) - 4)
+
+	custom := func(name string, data []byte) []byte {
+		content := append(append([]byte{byte(len(name))}, name...), data...)
+		return append([]byte{SecCustomID, byte(len(content))}, content...)
+	}
+	return testModule(
+		[]byte{SecTypeID, 0x04, 0x01, 0x60, 0x00, 0x00},
+		[]byte{SecFuncID, 0x02, 0x01, 0x00},
+		[]byte{SecCodeID, 0x06, 0x01, 0x04, 0x00, 0x01, 0x01, 0x0b},
+		custom(".debug_abbrev", abbrev),
+		custom(".debug_info", info),
+		custom(".debug_line", line))
+}
+
+func TestDebugInfo(t *testing.T) {
+	module, err := DecodeModule(common.NewSliceBytes(dwarfModule()))
+	require.NoError(t, err)
+
+	debug, err := module.DebugInfo()
+	require.NoError(t, err)
+
+	loc, ok := debug.Lookup(3)
+	assert.True(t, ok)
+	assert.Equal(t, SourceLocation{File: "/src/a.c", Line: 10, Column: 3, Function: "add"}, loc)
+
+	loc, ok = debug.Lookup(5)
+	assert.True(t, ok)
+	assert.Equal(t, 11, loc.Line)
+	assert.Equal(t, "", loc.Function)
+
+	_, ok = debug.Lookup(6)
+	assert.False(t, ok)
+
+	// the first nop sits right after the body size and local count
+	codeSec := module.Sections[2]
+	loc, ok = debug.LookupSource(module, codeSec.Offset+3)
+	assert.True(t, ok)
+	assert.Equal(t, 10, loc.Line)
+
+	str, err := module.DisplayDisassembly()
+	require.NoError(t, err)
+	assert.Contains(t, str, " ; /src/a.c:10:3 (add)\n")
+	assert.Contains(t, str, " ; /src/a.c:11:3\n")
+}
+
+func TestNoDebugInfo(t *testing.T) {
+	module, err := DecodeModule(common.NewSliceBytes(testModule()))
+	require.NoError(t, err)
+	_, err = module.DebugInfo()
+	assert.ErrorIs(t, err, ErrNoDebugInfo)
+}
@@ -0,0 +1,424 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/opcode"
+)
+
+var ErrFeatureDisabled = errors.New("feature disabled")
+
+// requireFeature fails unless every feature of f is enabled. offset is the
+// position in the module binary of what needs them.
+func (module *Module) requireFeature(f common.Features, offset int) error {
+	if module.opts.Features.Has(f) {
+		return nil
+	}
+	missing := f &^ module.opts.Features
+	return fmt.Errorf("%w: %s used at 0x%x", ErrFeatureDisabled, missing, offset)
+}
+
+// checkExpr checks the features the instructions of a constant expression
+// need. Instructions are only decoded for this when some feature is
+// disabled.
+func (module *Module) checkExpr(expr *common.Expr) error {
+	if module.opts.Features.Has(common.FeaturesAll) {
+		return nil
+	}
+	return walkExpr(expr, func(instr *Instruction) error {
+		return module.requireFeature(instrFeature(instr), instr.Offset)
+	})
+}
+
+// walkExpr decodes the instructions of expr one at a time and calls f on
+// each.
+func walkExpr(expr *common.Expr, f func(instr *Instruction) error) error {
+	bs := common.NewSliceBytes(expr.Data)
+	for bs.Remaining() > 0 {
+		instr, err := decodeInstruction(bs, expr.Offset)
+		if err != nil {
+			return err
+		}
+		if err = f(&instr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heapTypeFeature returns the features a heap type needs.
+func heapTypeFeature(ht common.HeapType) common.Features {
+	switch {
+	case ht.IsIndex():
+		return common.FeatureTypedFunctionReferences
+	case ht == common.HeapTypeFunc || ht == common.HeapTypeExtern:
+		return common.FeatureReferenceTypes
+	case ht == common.HeapTypeExn || ht == common.HeapTypeNoExn:
+		return common.FeatureExceptionHandling
+	}
+	return common.FeatureGC
+}
+
+// valTypeFeature returns the features a value or storage type needs.
+func valTypeFeature(vt common.ValType) common.Features {
+	switch {
+	case vt == common.ValTypeV128:
+		return common.FeatureSIMD
+	case vt == common.StorageTypeI8 || vt == common.StorageTypeI16:
+		return common.FeatureGC
+	case !vt.IsRef():
+		return 0
+	case vt > 0xFF:
+		// only the shorthands of nullable references fit in a byte
+		return common.FeatureTypedFunctionReferences | heapTypeFeature(vt.HeapType())
+	}
+	return heapTypeFeature(vt.HeapType())
+}
+
+func valTypesFeature(vts []common.ValType) common.Features {
+	var f common.Features
+	for _, vt := range vts {
+		f |= valTypeFeature(vt)
+	}
+	return f
+}
+
+// funcTypeFeature returns the features a function type needs.
+func funcTypeFeature(ft *common.FuncType) common.Features {
+	f := valTypesFeature(ft.InputTypes) | valTypesFeature(ft.ReturnTypes)
+	if len(ft.ReturnTypes) > 1 {
+		f |= common.FeatureMultiValue
+	}
+	return f
+}
+
+// limitsFeature returns the features the limits of a memory need.
+func limitsFeature(limits *common.Limits) common.Features {
+	var f common.Features
+	if limits.Shared() {
+		f |= common.FeatureThreads
+	}
+	if limits.Is64() {
+		f |= common.FeatureMemory64
+	}
+	return f
+}
+
+func blockTypeFeature(bt common.BlockType) common.Features {
+	switch {
+	case bt == common.BlockTypeEmpty:
+		return 0
+	case bt.IsIndex():
+		return common.FeatureMultiValue
+	}
+	return valTypeFeature(bt.ValType())
+}
+
+// elemTypeFeature returns the features the element type of a table or
+// element segment needs. funcref tables predate reference types.
+func elemTypeFeature(vt common.ValType) common.Features {
+	if vt == common.ValTypeFuncRef {
+		return 0
+	}
+	return valTypeFeature(vt)
+}
+
+func memIdxFeature(mem common.MemIdx) common.Features {
+	if mem != 0 {
+		return common.FeatureMultiMemory
+	}
+	return 0
+}
+
+// memArgFeature returns the features a memarg needs: any memarg that
+// encodes a memory index, even 0, needs multi-memory.
+func memArgFeature(memArg MemArg) common.Features {
+	if memArg.HasMem {
+		return common.FeatureMultiMemory
+	}
+	return memIdxFeature(memArg.Mem)
+}
+
+func tableIdxFeature(table common.TableIdx) common.Features {
+	if table != 0 {
+		return common.FeatureReferenceTypes
+	}
+	return 0
+}
+
+// instrFeature returns the features an instruction needs.
+func instrFeature(instr *Instruction) common.Features {
+	switch instr.Opcode {
+	case opcode.Block, opcode.Loop, opcode.If:
+		return blockTypeFeature(instr.BlockType)
+	case opcode.TryTable:
+		return common.FeatureExceptionHandling | blockTypeFeature(instr.BlockType)
+	case opcode.Throw, opcode.ThrowRef:
+		return common.FeatureExceptionHandling
+	case opcode.CallIndirect:
+		return tableIdxFeature(instr.Table)
+	case opcode.ReturnCall, opcode.ReturnCallIndirect:
+		return common.FeatureTailCall
+	case opcode.CallRef, opcode.RefAsNonNull, opcode.BrOnNull, opcode.BrOnNonNull:
+		return common.FeatureTypedFunctionReferences
+	case opcode.ReturnCallRef:
+		return common.FeatureTypedFunctionReferences | common.FeatureTailCall
+	case opcode.SelectT:
+		return common.FeatureReferenceTypes | valTypesFeature(instr.Types)
+	case opcode.RefNull:
+		return common.FeatureReferenceTypes | valTypeFeature(instr.RefType)
+	case opcode.RefIsNull, opcode.RefFunc, opcode.TableGet, opcode.TableSet:
+		return common.FeatureReferenceTypes
+	case opcode.RefEq, opcode.PrefixFB:
+		return common.FeatureGC
+	case opcode.MemorySize, opcode.MemoryGrow:
+		return memIdxFeature(instr.Mem)
+	case opcode.I32Extend8S, opcode.I32Extend16S,
+		opcode.I64Extend8S, opcode.I64Extend16S, opcode.I64Extend32S:
+		return common.FeatureSignExtension
+	case opcode.PrefixFC:
+		return instrFeatureFC(instr)
+	case opcode.PrefixFD:
+		return common.FeatureSIMD | memArgFeature(instr.MemArg)
+	case opcode.PrefixFE:
+		return common.FeatureThreads | memArgFeature(instr.MemArg)
+	}
+	if instr.Opcode >= opcode.I32Load && instr.Opcode <= opcode.I64Store32 {
+		return memArgFeature(instr.MemArg)
+	}
+	return 0
+}
+
+func instrFeatureFC(instr *Instruction) common.Features {
+	switch instr.SubOpcode {
+	case opcode.MemoryInit, opcode.MemoryFill:
+		return common.FeatureBulkMemory | memIdxFeature(instr.Mem)
+	case opcode.MemoryCopy:
+		return common.FeatureBulkMemory | memIdxFeature(instr.Mem) | memIdxFeature(instr.Index)
+	case opcode.DataDrop, opcode.ElemDrop:
+		return common.FeatureBulkMemory
+	case opcode.TableInit:
+		return common.FeatureBulkMemory | tableIdxFeature(instr.Table)
+	case opcode.TableCopy:
+		return common.FeatureBulkMemory | tableIdxFeature(instr.Table) | tableIdxFeature(instr.Index)
+	case opcode.TableGrow, opcode.TableSize, opcode.TableFill:
+		return common.FeatureReferenceTypes
+	}
+	return common.FeatureNonTrappingFloatToInt
+}
+
+// checkSectionFeature checks the features a section id needs.
+func (module *Module) checkSectionFeature(secId byte, offset int) error {
+	switch secId {
+	case SecDataCountID:
+		return module.requireFeature(common.FeatureBulkMemory, offset)
+	case SecTagID:
+		return module.requireFeature(common.FeatureExceptionHandling, offset)
+	}
+	return nil
+}
+
+// checkSubType checks the features a type of the type section needs.
+func (module *Module) checkSubType(subType *common.SubType, offset int) error {
+	f := common.Features(0)
+	switch subType.Comp.Tag {
+	case common.TagFuncType:
+		f = funcTypeFeature(subType.Comp.Func)
+	default:
+		f = common.FeatureGC
+		for _, field := range subType.Comp.Fields {
+			f |= valTypeFeature(field.Type)
+		}
+	}
+	return module.requireFeature(f, offset)
+}
+
+// checkImport checks the features an import needs. It is called before imp
+// is added to the import section.
+func (module *Module) checkImport(imp *Import, offset int) error {
+	f := common.Features(0)
+	switch imp.Desc.Tag {
+	case ImportTagTable:
+		f = elemTypeFeature(imp.Desc.Table.ElemType) | module.tableCountFeature()
+	case ImportTagMem:
+		f = limitsFeature(imp.Desc.Mem.LimitsRef) | module.memCountFeature()
+	case ImportTagGlobal:
+		f = valTypeFeature(imp.Desc.Global.ValType)
+		if imp.Desc.Global.Mutable {
+			f |= common.FeatureMutableGlobals
+		}
+	case ImportTagTag:
+		f = common.FeatureExceptionHandling
+	}
+	return module.requireFeature(f, offset)
+}
+
+// tableCountFeature returns the features one more table needs.
+func (module *Module) tableCountFeature() common.Features {
+	n := len(module.TableSec)
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag == ImportTagTable {
+			n++
+		}
+	}
+	return tableIdxFeature(common.TableIdx(n))
+}
+
+// memCountFeature returns the features one more memory needs.
+func (module *Module) memCountFeature() common.Features {
+	n := len(module.MemSec)
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag == ImportTagMem {
+			n++
+		}
+	}
+	return memIdxFeature(common.MemIdx(n))
+}
+
+// checkTable checks the features a table of the table section needs. It is
+// called before the table is added to the table section.
+func (module *Module) checkTable(tableType *common.TableType, offset int) error {
+	f := elemTypeFeature(tableType.ElemType) | module.tableCountFeature()
+	if tableType.Init != nil {
+		f |= common.FeatureTypedFunctionReferences
+	}
+	if err := module.requireFeature(f, offset); err != nil {
+		return err
+	}
+	if tableType.Init != nil {
+		return module.checkExpr(tableType.Init)
+	}
+	return nil
+}
+
+// checkMemory checks the features a memory of the memory section needs. It
+// is called before the memory is added to the memory section.
+func (module *Module) checkMemory(memType *common.MemType, offset int) error {
+	return module.requireFeature(limitsFeature(memType.LimitsRef)|module.memCountFeature(), offset)
+}
+
+func (module *Module) checkGlobal(global *Global, offset int) error {
+	if err := module.requireFeature(valTypeFeature(global.Type.ValType), offset); err != nil {
+		return err
+	}
+	return module.checkExpr(global.Init)
+}
+
+func (module *Module) checkExport(export *Export, offset int) error {
+	switch export.Desc.Tag {
+	case ExportTagGlobal:
+		if globalType := module.globalType(export.Desc.Idx); globalType != nil && globalType.Mutable {
+			return module.requireFeature(common.FeatureMutableGlobals, offset)
+		}
+	case ExportTagTag:
+		return module.requireFeature(common.FeatureExceptionHandling, offset)
+	}
+	return nil
+}
+
+// globalType returns the type of a global in the global index space, or
+// nil if there is no such global.
+func (module *Module) globalType(idx common.GlobalIdx) *common.GlobalType {
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag != ImportTagGlobal {
+			continue
+		}
+		if idx == 0 {
+			return imp.Desc.Global
+		}
+		idx--
+	}
+	if int64(idx) >= int64(len(module.GlobalSec)) {
+		return nil
+	}
+	return module.GlobalSec[idx].Type
+}
+
+func (module *Module) checkElem(elem *Elem, offset int) error {
+	f := elemTypeFeature(elem.Type) | tableIdxFeature(elem.Table)
+	if elem.Mode != SegmentModeActive || elem.Exprs != nil {
+		f |= common.FeatureBulkMemory
+	}
+	if err := module.requireFeature(f, offset); err != nil {
+		return err
+	}
+	if elem.Offset != nil {
+		if err := module.checkExpr(elem.Offset); err != nil {
+			return err
+		}
+	}
+	for _, expr := range elem.Exprs {
+		if err := module.checkExpr(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (module *Module) checkData(data *Data, offset int) error {
+	f := memIdxFeature(data.Mem)
+	if data.Mode != SegmentModeActive {
+		f |= common.FeatureBulkMemory
+	}
+	if err := module.requireFeature(f, offset); err != nil {
+		return err
+	}
+	if data.Offset != nil {
+		return module.checkExpr(data.Offset)
+	}
+	return nil
+}
+
+// checkCode checks the idx-th body of the code section: the features its
+// locals and instructions need, the offsets of its memory instructions and
+// the results of its tail calls. Its instructions are only decoded for this
+// when walkCode says they may fail a check.
+func (module *Module) checkCode(idx int, code *Code) error {
+	for _, locals := range code.Locals {
+		if err := module.requireFeature(valTypeFeature(locals.Type), code.Offset); err != nil {
+			return err
+		}
+	}
+	caller, err := module.GetType(module.FuncSec[idx])
+	if err != nil {
+		return fmt.Errorf("%w: code %d: %v", ErrInvalid, idx, err)
+	}
+	if !module.walkCode(code.Expr.Data) {
+		return nil
+	}
+	return walkExpr(code.Expr, func(instr *Instruction) error {
+		if err := module.requireFeature(instrFeature(instr), instr.Offset); err != nil {
+			return err
+		}
+		if err := module.checkMemArg(instr); err != nil {
+			return err
+		}
+		return module.checkTailCall(caller, instr)
+	})
+}
+
+// walkCode reports whether checkCode has to decode the instructions of a
+// body: always when some feature is disabled, otherwise only when its bytes
+// may hold a tail call or a memarg offset of 2^32 or more, which takes a
+// LEB128 of at least five bytes. The scan does not tell opcodes from
+// immediates, so it may ask for a walk that finds nothing.
+func (module *Module) walkCode(data []byte) bool {
+	if !module.opts.Features.Has(common.FeaturesAll) {
+		return true
+	}
+	run := 0 // bytes in a row with the LEB128 continuation bit set
+	for _, b := range data {
+		switch b {
+		case opcode.ReturnCall, opcode.ReturnCallIndirect, opcode.ReturnCallRef:
+			return true
+		}
+		if b&0x80 == 0 {
+			run = 0
+		} else if run++; run == 4 {
+			return true
+		}
+	}
+	return false
+}
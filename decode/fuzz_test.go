@@ -0,0 +1,33 @@
+package decode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzDecodeModule(f *testing.F) {
+	files, err := filepath.Glob("../testdata/wasm/*.wasm")
+	require.NoError(f, err)
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(buf)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := DecodeOptions{
+			MaxBodySize:          1 << 16,
+			MaxDataSize:          1 << 16,
+			MaxCustomSectionSize: 1 << 16,
+		}
+		module, err := DecodeModuleWithOptions(common.NewSliceBytes(data), opts)
+		if err != nil {
+			return
+		}
+		require.NotNil(t, module)
+	})
+}
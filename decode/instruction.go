@@ -0,0 +1,585 @@
+package decode
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/opcode"
+)
+
+// Instruction is a single decoded instruction and its immediates. Only the
+// fields used by Opcode are set.
+type Instruction struct {
+	Offset    int // position of the opcode in the module binary
+	Opcode    byte
+	SubOpcode uint32            // prefixed instructions
+	BlockType common.BlockType  // block, loop, if, try_table
+	Index     uint32            // label, function, type, local, global, data, element or tag index, source of table.copy and memory.copy
+	Index2    uint32            // struct field, array.new_fixed length, data or element index of array instructions, source of array.copy
+	Table     common.TableIdx   // table instructions, call_indirect, destination of table.copy
+	Mem       common.MemIdx     // memory instructions without a memarg, destination of memory.copy
+	RefType   common.ValType    // ref.null, always nullable, ref.test and ref.cast
+	Types     []common.ValType  // typed select, source and target of br_on_cast
+	Labels    []common.LabelIdx // br_table targets, the default label last
+	Catches   []Catch           // try_table catch clauses
+	MemArg    MemArg            // loads and stores
+	Const     uint64            // *.const, floats as their IEEE 754 bits
+	V128      common.V128       // v128.const, lane indices of i8x16.shuffle
+	Lane      byte              // SIMD lane instructions
+}
+
+type MemArg struct {
+	Align  uint32
+	Mem    common.MemIdx
+	HasMem bool   // Mem was encoded, which only multi-memory allows
+	Offset uint64 // u64 so memory64 addresses can be reached
+}
+
+// Catch is a catch clause of try_table. A matching exception branches to
+// Label with the exception's values, followed by its exnref for the _ref
+// kinds.
+type Catch struct {
+	Kind  byte
+	Tag   uint32 // CatchKindCatch and CatchKindCatchRef only
+	Label common.LabelIdx
+}
+
+// Kinds of try_table catch clauses.
+const (
+	CatchKindCatch       = 0x00 // catch x l
+	CatchKindCatchRef    = 0x01 // catch_ref x l
+	CatchKindCatchAll    = 0x02 // catch_all l
+	CatchKindCatchAllRef = 0x03 // catch_all_ref l
+)
+
+var catchKindNames = [...]string{
+	CatchKindCatch:       "catch",
+	CatchKindCatchRef:    "catch_ref",
+	CatchKindCatchAll:    "catch_all",
+	CatchKindCatchAllRef: "catch_all_ref",
+}
+
+// memArgFlagMem is set in the encoded alignment when a memory index follows.
+const memArgFlagMem = 0x40
+
+// DecodeInstructions decodes an expression into its instructions.
+func DecodeInstructions(expr *common.Expr) ([]Instruction, error) {
+	bs := common.NewSliceBytes(expr.Data)
+	instrs := make([]Instruction, 0, len(expr.Data)/2)
+	for bs.Remaining() > 0 {
+		instr, err := decodeInstruction(bs, expr.Offset)
+		if err != nil {
+			return instrs, err
+		}
+		instrs = append(instrs, instr)
+	}
+	return instrs, nil
+}
+
+// decodeInstruction decodes the instruction at bs. base is added to offsets
+// inside bs to turn them into module offsets.
+func decodeInstruction(bs *common.SliceBytes, base int) (Instruction, error) {
+	instr := Instruction{Offset: base + bs.Offset()}
+	op, err := bs.ReadByte()
+	if err != nil {
+		return instr, err
+	}
+	instr.Opcode = op
+
+	switch op {
+	case opcode.Block, opcode.Loop, opcode.If:
+		instr.BlockType, err = decodeBlockType(bs)
+	case opcode.TryTable:
+		if instr.BlockType, err = decodeBlockType(bs); err != nil {
+			return instr, err
+		}
+		instr.Catches, err = decodeCatches(bs)
+	case opcode.Br, opcode.BrIf, opcode.BrOnNull, opcode.BrOnNonNull,
+		opcode.Call, opcode.ReturnCall, opcode.CallRef, opcode.ReturnCallRef, opcode.Throw,
+		opcode.LocalGet, opcode.LocalSet, opcode.LocalTee,
+		opcode.GlobalGet, opcode.GlobalSet:
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.BrTable:
+		instr.Labels, err = decodeLabels(bs)
+	case opcode.RefFunc:
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.TableGet, opcode.TableSet:
+		instr.Table, _, err = common.DecodeUint32(bs)
+	case opcode.RefNull:
+		var ht common.HeapType
+		if ht, err = decodeHeapType(bs); err != nil {
+			err = fmt.Errorf("ref.null: %w", err)
+		}
+		instr.RefType = common.RefType(ht, true)
+	case opcode.SelectT:
+		instr.Types, err = decodeValueTypes(bs)
+	case opcode.CallIndirect, opcode.ReturnCallIndirect:
+		if instr.Index, _, err = common.DecodeUint32(bs); err != nil {
+			return instr, err
+		}
+		instr.Table, _, err = common.DecodeUint32(bs)
+	case opcode.MemorySize, opcode.MemoryGrow:
+		instr.Mem, _, err = common.DecodeUint32(bs)
+	case opcode.I32Const:
+		var n int32
+		n, _, err = common.DecodeInt32(bs)
+		instr.Const = uint64(uint32(n))
+	case opcode.I64Const:
+		var n int64
+		n, _, err = common.DecodeInt64(bs)
+		instr.Const = uint64(n)
+	case opcode.F32Const:
+		var bits uint32
+		bits, err = bs.ReadUint32()
+		instr.Const = uint64(bits)
+	case opcode.F64Const:
+		instr.Const, err = bs.ReadUint64()
+	case opcode.PrefixFB:
+		err = decodeInstructionFB(bs, &instr)
+	case opcode.PrefixFC:
+		err = decodeInstructionFC(bs, &instr)
+	case opcode.PrefixFD:
+		err = decodeInstructionFD(bs, &instr)
+	case opcode.PrefixFE:
+		err = decodeInstructionFE(bs, &instr)
+	default:
+		switch {
+		case op >= opcode.I32Load && op <= opcode.I64Store32:
+			instr.MemArg, err = decodeMemArg(bs)
+		case !opcode.IsValid(op):
+			err = fmt.Errorf("unknown opcode 0x%02x", op)
+		}
+	}
+	if err != nil {
+		return instr, fmt.Errorf("decode instruction at 0x%x: %w", instr.Offset, err)
+	}
+	return instr, nil
+}
+
+// brOnCastFlag* are set in the flags of br_on_cast and br_on_cast_fail when
+// the source or target type is nullable.
+const (
+	brOnCastFlagSourceNull = 0x01
+	brOnCastFlagTargetNull = 0x02
+)
+
+// decodeInstructionFB decodes the sub-opcode and immediates of an instruction
+// prefixed with opcode.PrefixFB.
+func decodeInstructionFB(bs *common.SliceBytes, instr *Instruction) (err error) {
+	if instr.SubOpcode, _, err = common.DecodeUint32(bs); err != nil {
+		return err
+	}
+
+	switch instr.SubOpcode {
+	case opcode.StructNew, opcode.StructNewDefault,
+		opcode.ArrayNew, opcode.ArrayNewDefault,
+		opcode.ArrayGet, opcode.ArrayGetS, opcode.ArrayGetU, opcode.ArraySet, opcode.ArrayFill:
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.StructGet, opcode.StructGetS, opcode.StructGetU, opcode.StructSet,
+		opcode.ArrayNewFixed, opcode.ArrayNewData, opcode.ArrayNewElem,
+		opcode.ArrayCopy, opcode.ArrayInitData, opcode.ArrayInitElem:
+		if instr.Index, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		instr.Index2, _, err = common.DecodeUint32(bs)
+	case opcode.RefTest, opcode.RefTestNull, opcode.RefCast, opcode.RefCastNull:
+		var ht common.HeapType
+		ht, err = decodeHeapType(bs)
+		nullable := instr.SubOpcode == opcode.RefTestNull || instr.SubOpcode == opcode.RefCastNull
+		instr.RefType = common.RefType(ht, nullable)
+	case opcode.BrOnCast, opcode.BrOnCastFail:
+		var flags byte
+		if flags, err = bs.ReadByte(); err != nil {
+			return err
+		}
+		if flags&^(brOnCastFlagSourceNull|brOnCastFlagTargetNull) != 0 {
+			return fmt.Errorf("invalid br_on_cast flags 0x%02x", flags)
+		}
+		if instr.Index, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		source, err := decodeHeapType(bs)
+		if err != nil {
+			return err
+		}
+		target, err := decodeHeapType(bs)
+		if err != nil {
+			return err
+		}
+		instr.Types = []common.ValType{
+			common.RefType(source, flags&brOnCastFlagSourceNull != 0),
+			common.RefType(target, flags&brOnCastFlagTargetNull != 0),
+		}
+	case opcode.ArrayLen, opcode.AnyConvertExtern, opcode.ExternConvertAny,
+		opcode.RefI31, opcode.I31GetS, opcode.I31GetU:
+	default:
+		return fmt.Errorf("unknown opcode 0xfb %d", instr.SubOpcode)
+	}
+	return err
+}
+
+// decodeInstructionFC decodes the sub-opcode and immediates of an instruction
+// prefixed with opcode.PrefixFC.
+func decodeInstructionFC(bs *common.SliceBytes, instr *Instruction) (err error) {
+	if instr.SubOpcode, _, err = common.DecodeUint32(bs); err != nil {
+		return err
+	}
+
+	switch instr.SubOpcode {
+	case opcode.MemoryInit:
+		if instr.Index, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		instr.Mem, _, err = common.DecodeUint32(bs)
+	case opcode.DataDrop, opcode.ElemDrop:
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.MemoryCopy:
+		if instr.Mem, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.MemoryFill:
+		instr.Mem, _, err = common.DecodeUint32(bs)
+	case opcode.TableInit:
+		if instr.Index, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		instr.Table, _, err = common.DecodeUint32(bs)
+	case opcode.TableCopy:
+		if instr.Table, _, err = common.DecodeUint32(bs); err != nil {
+			return err
+		}
+		instr.Index, _, err = common.DecodeUint32(bs)
+	case opcode.TableGrow, opcode.TableSize, opcode.TableFill:
+		instr.Table, _, err = common.DecodeUint32(bs)
+	default:
+		if !opcode.IsValidFC(instr.SubOpcode) {
+			err = fmt.Errorf("unknown opcode 0xfc %d", instr.SubOpcode)
+		}
+	}
+	return err
+}
+
+// decodeInstructionFD decodes the sub-opcode and immediates of a SIMD
+// instruction.
+func decodeInstructionFD(bs *common.SliceBytes, instr *Instruction) (err error) {
+	if instr.SubOpcode, _, err = common.DecodeUint32(bs); err != nil {
+		return err
+	}
+
+	switch sub := instr.SubOpcode; {
+	case sub <= opcode.V128Store, sub == opcode.V128Load32Zero, sub == opcode.V128Load64Zero:
+		instr.MemArg, err = decodeMemArg(bs)
+	case sub == opcode.V128Const, sub == opcode.I8x16Shuffle:
+		instr.V128, err = decodeV128(bs)
+	case sub >= opcode.I8x16ExtractLaneS && sub <= opcode.F64x2ReplaceLane:
+		instr.Lane, err = bs.ReadByte()
+	case sub >= opcode.V128Load8Lane && sub <= opcode.V128Store64Lane:
+		if instr.MemArg, err = decodeMemArg(bs); err != nil {
+			return err
+		}
+		instr.Lane, err = bs.ReadByte()
+	case !opcode.IsValidFD(sub):
+		err = fmt.Errorf("unknown opcode 0xfd %d", sub)
+	}
+	return err
+}
+
+// decodeInstructionFE decodes the sub-opcode and immediates of an atomic
+// instruction.
+func decodeInstructionFE(bs *common.SliceBytes, instr *Instruction) (err error) {
+	if instr.SubOpcode, _, err = common.DecodeUint32(bs); err != nil {
+		return err
+	}
+
+	switch {
+	case instr.SubOpcode == opcode.AtomicFence:
+		return decodeZeroBytes(bs, instr, 1)
+	case !opcode.IsValidFE(instr.SubOpcode):
+		return fmt.Errorf("unknown opcode 0xfe %d", instr.SubOpcode)
+	}
+	instr.MemArg, err = decodeMemArg(bs)
+	return err
+}
+
+func decodeV128(bs *common.SliceBytes) (v common.V128, err error) {
+	if v.Lo, err = bs.ReadUint64(); err != nil {
+		return v, err
+	}
+	v.Hi, err = bs.ReadUint64()
+	return v, err
+}
+
+// decodeZeroBytes reads reserved bytes, which must be zero.
+func decodeZeroBytes(bs *common.SliceBytes, instr *Instruction, n int) error {
+	for i := 0; i < n; i++ {
+		zero, err := bs.ReadByte()
+		if err != nil {
+			return err
+		}
+		if zero != 0 {
+			return fmt.Errorf("%s: expected zero byte, got 0x%02x", instr.Name(), zero)
+		}
+	}
+	return nil
+}
+
+func decodeBlockType(bs *common.SliceBytes) (common.BlockType, error) {
+	n, _, err := common.DecodeInt33AsInt64(bs)
+	if err != nil {
+		return 0, err
+	}
+	bt := common.BlockType(n)
+	if n < -0x40 {
+		// not the single byte encoding of a value type
+		return 0, fmt.Errorf("invalid block type %d", n)
+	}
+	if vt := bt.ValType(); vt == common.ValTypeRef || vt == common.ValTypeRefNull {
+		ht, err := decodeHeapType(bs)
+		if err != nil {
+			return 0, err
+		}
+		return common.BlockTypeOf(common.RefType(ht, vt == common.ValTypeRefNull)), nil
+	}
+	if !bt.IsIndex() && bt != common.BlockTypeEmpty && !bt.ValType().IsValid() {
+		return 0, fmt.Errorf("invalid block type %d", n)
+	}
+	return bt, nil
+}
+
+func decodeLabels(bs *common.SliceBytes) ([]common.LabelIdx, error) {
+	count, err := decodeVecCount(bs, "br_table label")
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]common.LabelIdx, 0, count+1)
+	for i := uint32(0); i <= count; i++ {
+		label, _, err := common.DecodeUint32(bs)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+func decodeCatches(bs *common.SliceBytes) ([]Catch, error) {
+	count, err := decodeVecCount(bs, "try_table catch")
+	if err != nil {
+		return nil, err
+	}
+	catches := make([]Catch, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var c Catch
+		if c.Kind, err = bs.ReadByte(); err != nil {
+			return nil, err
+		}
+		switch c.Kind {
+		case CatchKindCatch, CatchKindCatchRef:
+			if c.Tag, _, err = common.DecodeUint32(bs); err != nil {
+				return nil, err
+			}
+		case CatchKindCatchAll, CatchKindCatchAllRef:
+		default:
+			return nil, fmt.Errorf("invalid catch kind 0x%02x", c.Kind)
+		}
+		if c.Label, _, err = common.DecodeUint32(bs); err != nil {
+			return nil, err
+		}
+		catches = append(catches, c)
+	}
+	return catches, nil
+}
+
+func decodeMemArg(bs *common.SliceBytes) (MemArg, error) {
+	var memArg MemArg
+	align, _, err := common.DecodeUint32(bs)
+	if err != nil {
+		return memArg, err
+	}
+	memArg.Align = align &^ memArgFlagMem
+	if align&memArgFlagMem != 0 {
+		memArg.HasMem = true
+		if memArg.Mem, _, err = common.DecodeUint32(bs); err != nil {
+			return memArg, err
+		}
+	}
+	// memory64 widened the offset; Module.checkMemArg rejects one that
+	// does not fit a 32-bit memory
+	memArg.Offset, _, err = common.DecodeUint64(bs)
+	return memArg, err
+}
+
+// Name returns the mnemonic of the instruction.
+func (instr Instruction) Name() string {
+	switch instr.Opcode {
+	case opcode.PrefixFB:
+		return opcode.NameFB(instr.SubOpcode)
+	case opcode.PrefixFC:
+		return opcode.NameFC(instr.SubOpcode)
+	case opcode.PrefixFD:
+		return opcode.NameFD(instr.SubOpcode)
+	case opcode.PrefixFE:
+		return opcode.NameFE(instr.SubOpcode)
+	}
+	return opcode.Name(instr.Opcode)
+}
+
+// String formats the instruction in the text format, e.g. `i32.load offset=4`.
+func (instr Instruction) String() string {
+	name := instr.Name()
+	switch instr.Opcode {
+	case opcode.PrefixFB:
+		return instr.stringFB(name)
+	case opcode.PrefixFC:
+		switch instr.SubOpcode {
+		case opcode.MemoryInit:
+			return fmt.Sprintf("%s%s %d", name, displayMemIdx(instr.Mem), instr.Index)
+		case opcode.MemoryCopy:
+			if instr.Mem == 0 && instr.Index == 0 {
+				return name
+			}
+			return fmt.Sprintf("%s %d %d", name, instr.Mem, instr.Index)
+		case opcode.MemoryFill:
+			return name + displayMemIdx(instr.Mem)
+		case opcode.DataDrop, opcode.ElemDrop:
+			return fmt.Sprintf("%s %d", name, instr.Index)
+		case opcode.TableInit, opcode.TableCopy:
+			return fmt.Sprintf("%s %d %d", name, instr.Table, instr.Index)
+		case opcode.TableGrow, opcode.TableSize, opcode.TableFill:
+			return fmt.Sprintf("%s %d", name, instr.Table)
+		}
+		return name
+	case opcode.PrefixFD:
+		return instr.stringFD(name)
+	case opcode.PrefixFE:
+		if instr.SubOpcode == opcode.AtomicFence {
+			return name
+		}
+		return name + displayMemArg(instr.MemArg)
+	case opcode.Block, opcode.Loop, opcode.If:
+		return name + displayBlockType(instr.BlockType)
+	case opcode.TryTable:
+		str := name + displayBlockType(instr.BlockType)
+		for _, c := range instr.Catches {
+			if c.Kind == CatchKindCatch || c.Kind == CatchKindCatchRef {
+				str += fmt.Sprintf(" (%s %d %d)", catchKindNames[c.Kind], c.Tag, c.Label)
+			} else {
+				str += fmt.Sprintf(" (%s %d)", catchKindNames[c.Kind], c.Label)
+			}
+		}
+		return str
+	case opcode.Br, opcode.BrIf, opcode.BrOnNull, opcode.BrOnNonNull,
+		opcode.Call, opcode.ReturnCall, opcode.Throw,
+		opcode.LocalGet, opcode.LocalSet, opcode.LocalTee,
+		opcode.GlobalGet, opcode.GlobalSet:
+		return fmt.Sprintf("%s %d", name, instr.Index)
+	case opcode.BrTable:
+		labels := make([]string, 0, len(instr.Labels))
+		for _, label := range instr.Labels {
+			labels = append(labels, fmt.Sprintf("%d", label))
+		}
+		return name + " " + strings.Join(labels, " ")
+	case opcode.CallIndirect, opcode.ReturnCallIndirect:
+		return fmt.Sprintf("%s %d (type %d)", name, instr.Table, instr.Index)
+	case opcode.CallRef, opcode.ReturnCallRef:
+		return fmt.Sprintf("%s (type %d)", name, instr.Index)
+	case opcode.MemorySize, opcode.MemoryGrow:
+		return name + displayMemIdx(instr.Mem)
+	case opcode.RefFunc:
+		return fmt.Sprintf("%s %d", name, instr.Index)
+	case opcode.TableGet, opcode.TableSet:
+		return fmt.Sprintf("%s %d", name, instr.Table)
+	case opcode.RefNull:
+		return name + " " + displayHeapType(instr.RefType.HeapType())
+	case opcode.SelectT:
+		types := make([]string, 0, len(instr.Types))
+		for _, t := range instr.Types {
+			types = append(types, displayValType(t))
+		}
+		return fmt.Sprintf("%s (result %s)", name, strings.Join(types, " "))
+	case opcode.I32Const:
+		return fmt.Sprintf("%s %d", name, int32(instr.Const))
+	case opcode.I64Const:
+		return fmt.Sprintf("%s %d", name, int64(instr.Const))
+	case opcode.F32Const:
+		return fmt.Sprintf("%s %v", name, math.Float32frombits(uint32(instr.Const)))
+	case opcode.F64Const:
+		return fmt.Sprintf("%s %v", name, math.Float64frombits(instr.Const))
+	}
+	if instr.Opcode >= opcode.I32Load && instr.Opcode <= opcode.I64Store32 {
+		return name + displayMemArg(instr.MemArg)
+	}
+	return name
+}
+
+func (instr Instruction) stringFB(name string) string {
+	switch instr.SubOpcode {
+	case opcode.StructNew, opcode.StructNewDefault,
+		opcode.ArrayNew, opcode.ArrayNewDefault,
+		opcode.ArrayGet, opcode.ArrayGetS, opcode.ArrayGetU, opcode.ArraySet, opcode.ArrayFill:
+		return fmt.Sprintf("%s %d", name, instr.Index)
+	case opcode.StructGet, opcode.StructGetS, opcode.StructGetU, opcode.StructSet,
+		opcode.ArrayNewFixed, opcode.ArrayNewData, opcode.ArrayNewElem,
+		opcode.ArrayCopy, opcode.ArrayInitData, opcode.ArrayInitElem:
+		return fmt.Sprintf("%s %d %d", name, instr.Index, instr.Index2)
+	case opcode.RefTest, opcode.RefTestNull, opcode.RefCast, opcode.RefCastNull:
+		return name + " " + displayValType(instr.RefType)
+	case opcode.BrOnCast, opcode.BrOnCastFail:
+		return fmt.Sprintf("%s %d %s %s", name, instr.Index, displayValType(instr.Types[0]), displayValType(instr.Types[1]))
+	}
+	return name
+}
+
+func (instr Instruction) stringFD(name string) string {
+	switch sub := instr.SubOpcode; {
+	case sub <= opcode.V128Store, sub == opcode.V128Load32Zero, sub == opcode.V128Load64Zero:
+		return name + displayMemArg(instr.MemArg)
+	case sub == opcode.V128Const:
+		return fmt.Sprintf("%s i32x4 0x%08x 0x%08x 0x%08x 0x%08x", name,
+			uint32(instr.V128.Lo), uint32(instr.V128.Lo>>32), uint32(instr.V128.Hi), uint32(instr.V128.Hi>>32))
+	case sub == opcode.I8x16Shuffle:
+		lanes := make([]string, 0, 16)
+		for _, half := range []uint64{instr.V128.Lo, instr.V128.Hi} {
+			for i := 0; i < 64; i += 8 {
+				lanes = append(lanes, fmt.Sprintf("%d", byte(half>>i)))
+			}
+		}
+		return name + " " + strings.Join(lanes, " ")
+	case sub >= opcode.I8x16ExtractLaneS && sub <= opcode.F64x2ReplaceLane:
+		return fmt.Sprintf("%s %d", name, instr.Lane)
+	case sub >= opcode.V128Load8Lane && sub <= opcode.V128Store64Lane:
+		return fmt.Sprintf("%s%s %d", name, displayMemArg(instr.MemArg), instr.Lane)
+	}
+	return name
+}
+
+// displayBlockType is the block type of a block instruction, with a leading
+// space unless it is empty.
+func displayBlockType(bt common.BlockType) string {
+	switch {
+	case bt == common.BlockTypeEmpty:
+		return ""
+	case bt.IsIndex():
+		return fmt.Sprintf(" (type %d)", bt)
+	}
+	return fmt.Sprintf(" (result %s)", displayValType(bt.ValType()))
+}
+
+// displayMemIdx formats a memory immediate, which is left out for memory 0.
+func displayMemIdx(mem common.MemIdx) string {
+	if mem == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %d", mem)
+}
+
+func displayMemArg(memArg MemArg) string {
+	str := displayMemIdx(memArg.Mem)
+	if memArg.Offset != 0 {
+		str += fmt.Sprintf(" offset=%d", memArg.Offset)
+	}
+	return str + fmt.Sprintf(" align=%d", uint64(1)<<(memArg.Align&63))
+}
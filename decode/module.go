@@ -3,6 +3,9 @@ package decode
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 
 	"github.com/luyiming112233/wasm/common"
 )
@@ -12,9 +15,19 @@ const (
 	Version     = 0x00000001 // 1
 )
 
+// the most pages a memory may declare, matching interpreter.MaxPages and
+// interpreter.MaxPages64
+const (
+	maxMemPages   = 65536
+	maxMemPages64 = 1 << 48
+)
+
 var (
 	ErrInvalidMagicNumber = errors.New("invalid magic number")
 	ErrInvalidVersion     = errors.New("invalid version header")
+	// ErrInvalid is wrapped by the errors of modules that are well-formed
+	// but break a validation rule the decoder checks along the way.
+	ErrInvalid = errors.New("invalid module")
 )
 
 const (
@@ -30,44 +43,115 @@ const (
 	SecElemID
 	SecCodeID
 	SecDataID
+	SecDataCountID
+	SecTagID
 )
 
+// sectionOrder is the position each non-custom section must appear at. Ids
+// are in order except for later additions such as the data count section,
+// which sits between the element and code sections, and the tag section,
+// which sits between the memory and global sections.
+var sectionOrder = map[byte]int{
+	SecTypeID:      1,
+	SecImportID:    2,
+	SecFuncID:      3,
+	SecTableID:     4,
+	SecMemID:       5,
+	SecTagID:       6,
+	SecGlobalID:    7,
+	SecExportID:    8,
+	SecStartID:     9,
+	SecElemID:      10,
+	SecDataCountID: 11,
+	SecCodeID:      12,
+	SecDataID:      13,
+}
+
+// Segment modes of element and data segments.
+const (
+	SegmentModeActive      = 0
+	SegmentModePassive     = 1
+	SegmentModeDeclarative = 2 // element segments only
+)
+
+// Flags of element and data segment encodings.
+const (
+	segmentFlagPassive     = 0x01 // passive or declarative when set
+	segmentFlagExplicitIdx = 0x02 // active with an explicit table or memory index
+	segmentFlagExprs       = 0x04 // element segments only, init given as expressions
+)
+
+// tableFlagInit starts a table of the table section that has an init
+// expression.
+const tableFlagInit = 0x40
+
+// ElemKindFuncRef is the only elemkind of the bulk memory encodings.
+const ElemKindFuncRef = 0x00
+
 const (
 	ImportTagFunc   = 0
 	ImportTagTable  = 1
 	ImportTagMem    = 2
 	ImportTagGlobal = 3
+	ImportTagTag    = 4
 )
 
-const TableTypeTag = 0x70
-
 const (
 	ExportTagFunc   = 0
 	ExportTagTable  = 1
 	ExportTagMem    = 2
 	ExportTagGlobal = 3
+	ExportTagTag    = 4
 )
 
 type Module struct {
 	Magic      uint32
 	Version    uint32
 	CustomSecs []CustomSec
-	TypeSec    []*common.FuncType
+	TypeSec    []*common.SubType // recursion groups flattened
 	ImportSec  []*Import
 	FuncSec    []common.TypeIdx
 	TableSec   []common.TableType
 	MemSec     []common.MemType
+	TagSec     []common.TagType
 	GlobalSec  []*Global
 	ExportSec  []*Export
 	StartSec   common.FuncIdx
 	ElemSec    []*Elem
 	CodeSec    []*Code
 	DataSec    []*Data
+	// DataCountSec is the data count section, nil if the module has none
+	DataCountSec *uint32
+
+	// Sections lists the sections in the order they appear in the binary
+	Sections []SectionHeader
+	// Names is the decoded first name custom section, if the module has a
+	// valid one
+	Names *NameSec
+	// Producers and TargetFeatures are the decoded tool-conventions
+	// sections of the same names, if present and valid
+	Producers      *ProducersSec
+	TargetFeatures *TargetFeaturesSec
+
+	opts DecodeOptions
+}
+
+type SectionHeader struct {
+	ID     byte
+	Name   string // custom sections only
+	Offset int    // position of the section content, after its size
+	Size   int
 }
 
 // DecodeModule decodes a `raw` module from io.Reader whose index spaces are yet to be initialized
 func DecodeModule(bs *common.SliceBytes) (*Module, error) {
-	module := &Module{}
+	return DecodeModuleWithOptions(bs, DefaultDecodeOptions())
+}
+
+// DecodeModuleWithOptions is DecodeModule with caller supplied limits. It
+// returns an error rather than panicking on any malformed or hostile input.
+func DecodeModuleWithOptions(bs *common.SliceBytes, opts DecodeOptions) (*Module, error) {
+	module := &Module{opts: opts.withDefaults()}
 	var err error
 
 	// 解析Magic
@@ -92,13 +176,24 @@ func DecodeModule(bs *common.SliceBytes) (*Module, error) {
 	if err = module.decodeSections(bs); err != nil {
 		return module, err
 	}
+	if opts.CheckTargetFeatures {
+		if err = module.CheckTargetFeatures(); err != nil {
+			return module, err
+		}
+	}
 
 	return module, nil
 }
 
 type CustomSec struct {
 	Name  string
-	Bytes []byte // TODO
+	Bytes []byte
+	// After is the id of the last non-custom section before this one, or
+	// SecCustomID if it precedes them all
+	After byte
+	// Value and Err are the result of the registered decoder, if any
+	Value any
+	Err   error
 }
 
 type Import struct {
@@ -113,6 +208,7 @@ type ImportDesc struct {
 	Table    *common.TableType  // tag=1
 	Mem      *common.MemType    // tag=2
 	Global   *common.GlobalType // tag=3
+	ExnTag   *common.TagType    // tag=4
 }
 
 type Global struct {
@@ -130,14 +226,25 @@ type ExportDesc struct {
 }
 
 type Elem struct {
-	Table  common.TableIdx
-	Offset *common.Expr
+	Mode   byte
+	Type   common.ValType  // funcref or externref
+	Table  common.TableIdx // active only
+	Offset *common.Expr    // active only
 	Init   []common.FuncIdx
+	Exprs  []*common.Expr // instead of Init for flags 4-7
 }
 
+// Code is a function body. With DecodeOptions.LazyCode, Locals and Expr are
+// nil until Module.DecodeCode decodes the body.
 type Code struct {
 	Locals []Locals
 	Expr   *common.Expr
+	Offset int // position of the body, after its size, in the module binary
+	Size   int
+
+	body *common.SliceBytes // the undecoded body
+	once sync.Once
+	err  error
 }
 type Locals struct {
 	N    uint32
@@ -145,12 +252,13 @@ type Locals struct {
 }
 
 type Data struct {
-	Mem    common.MemIdx
-	Offset *common.Expr
+	Mode   byte
+	Mem    common.MemIdx // active only
+	Offset *common.Expr  // active only
 	Init   []byte
 }
 
-func (code Code) GetLocalCount() uint64 {
+func (code *Code) GetLocalCount() uint64 {
 	n := uint64(0)
 	for _, locals := range code.Locals {
 		n += uint64(locals.N)
@@ -158,8 +266,156 @@ func (code Code) GetLocalCount() uint64 {
 	return n
 }
 
+// ImportedFuncCount returns the number of imported functions, which come
+// first in the function index space.
+func (module *Module) ImportedFuncCount() int {
+	n := 0
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag == ImportTagFunc {
+			n++
+		}
+	}
+	return n
+}
+
+// GetFuncType returns the type of a function in the function index space.
+func (module *Module) GetFuncType(idx common.FuncIdx) (*common.FuncType, error) {
+	var typeIdx common.TypeIdx
+	imported := module.ImportedFuncCount()
+	if int64(idx) < int64(imported) {
+		for _, imp := range module.ImportSec {
+			if imp.Desc.Tag != ImportTagFunc {
+				continue
+			}
+			if idx == 0 {
+				typeIdx = imp.Desc.FuncType
+				break
+			}
+			idx--
+		}
+	} else if int64(idx)-int64(imported) < int64(len(module.FuncSec)) {
+		typeIdx = module.FuncSec[int(idx)-imported]
+	} else {
+		return nil, fmt.Errorf("unknown function %d", idx)
+	}
+
+	return module.GetType(typeIdx)
+}
+
+// GetCode returns the body of the function at idx in the function index
+// space, decoding it first if it was decoded lazily.
+func (module *Module) GetCode(idx common.FuncIdx) (*Code, error) {
+	imported := module.ImportedFuncCount()
+	if int64(idx) < int64(imported) {
+		return nil, fmt.Errorf("function %d is imported", idx)
+	}
+	if int64(idx)-int64(imported) >= int64(len(module.CodeSec)) {
+		return nil, fmt.Errorf("unknown function %d", idx)
+	}
+	return module.DecodeCode(int(idx) - imported)
+}
+
+// DecodeCode returns the idx-th body of the code section with its locals
+// and instructions decoded. Lazily decoded bodies are decoded and checked
+// on the first call, which is safe to make from several goroutines; later
+// calls return the same result.
+func (module *Module) DecodeCode(idx int) (*Code, error) {
+	if idx < 0 || idx >= len(module.CodeSec) {
+		return nil, fmt.Errorf("unknown code %d", idx)
+	}
+	code := module.CodeSec[idx]
+	code.once.Do(func() {
+		if code.body != nil {
+			code.err = module.decodeBody(idx, code, code.body)
+			code.body = nil
+		}
+	})
+	if code.err != nil {
+		return nil, code.err
+	}
+	return code, nil
+}
+
+// GetType returns the function type at idx in the type section.
+func (module *Module) GetType(idx common.TypeIdx) (*common.FuncType, error) {
+	if int64(idx) >= int64(len(module.TypeSec)) {
+		return nil, fmt.Errorf("unknown type %d", idx)
+	}
+	if module.TypeSec[idx].Comp.Tag != common.TagFuncType {
+		return nil, fmt.Errorf("type %d is not a function type", idx)
+	}
+	return module.TypeSec[idx].Comp.Func, nil
+}
+
+// GetMemType returns the type of a memory in the memory index space, which
+// starts with the imported memories.
+func (module *Module) GetMemType(idx common.MemIdx) (*common.MemType, error) {
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag != ImportTagMem {
+			continue
+		}
+		if idx == 0 {
+			return imp.Desc.Mem, nil
+		}
+		idx--
+	}
+	if int64(idx) >= int64(len(module.MemSec)) {
+		return nil, fmt.Errorf("unknown memory %d", idx)
+	}
+	return &module.MemSec[idx], nil
+}
+
+// GetTagType returns the function type of a tag in the tag index space,
+// which starts with the imported tags. Its parameters are the values the
+// tag's exceptions carry.
+func (module *Module) GetTagType(idx uint32) (*common.FuncType, error) {
+	for _, imp := range module.ImportSec {
+		if imp.Desc.Tag != ImportTagTag {
+			continue
+		}
+		if idx == 0 {
+			return module.tagFuncType(imp.Desc.ExnTag)
+		}
+		idx--
+	}
+	if int64(idx) >= int64(len(module.TagSec)) {
+		return nil, fmt.Errorf("unknown tag %d", idx)
+	}
+	return module.tagFuncType(&module.TagSec[idx])
+}
+
+func (module *Module) tagFuncType(tagType *common.TagType) (*common.FuncType, error) {
+	ft, err := module.GetType(tagType.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%w: tag: %v", ErrInvalid, err)
+	}
+	if len(ft.ReturnTypes) != 0 {
+		return nil, fmt.Errorf("%w: tag type %d has results", ErrInvalid, tagType.Type)
+	}
+	return ft, nil
+}
+
+// GetExport returns the export with the given name, or nil.
+func (module *Module) GetExport(name string) *Export {
+	for _, exp := range module.ExportSec {
+		if exp.Name == name {
+			return exp
+		}
+	}
+	return nil
+}
+
+// CheckTargetFeatures checks the target_features section, if any, against
+// the DecodeOptions.Features the module was decoded with.
+func (module *Module) CheckTargetFeatures() error {
+	if module.TargetFeatures == nil {
+		return nil
+	}
+	return module.TargetFeatures.Check(module.opts.Features)
+}
+
 func (module *Module) decodeSections(bs *common.SliceBytes) (err error) {
-	prevSecID := byte(0)
+	prevOrder := 0
 
 	for bs.Remaining() > 0 {
 		var secId byte
@@ -174,28 +430,57 @@ func (module *Module) decodeSections(bs *common.SliceBytes) (err error) {
 				return err
 			}
 		} else {
-			// secId一定是递增的且小于SecDataID
-			if secId < prevSecID || secId > SecDataID {
+			// 非自定义段必须按sectionOrder的顺序出现且不能重复
+			order, ok := sectionOrder[secId]
+			if !ok || order <= prevOrder {
 				return errors.New("invalid section id")
 			}
 			// 解析非自定义段
 			// 读取当前段的section长度
-			_, _, err := common.DecodeInt32(bs)
+			size, err := decodeSize(bs, math.MaxUint32, "section")
+			if err != nil {
+				return err
+			}
+			header := SectionHeader{ID: secId, Offset: bs.Offset(), Size: size}
+			sec, err := bs.ReadSlice(size)
 			if err != nil {
 				return err
 			}
+			module.Sections = append(module.Sections, header)
 
-			if err = module.decodeNonSection(secId, bs); err != nil {
+			if err = module.decodeNonSection(secId, sec); err != nil {
 				return err
 			}
-			prevSecID = secId
+			if sec.Remaining() != 0 {
+				return fmt.Errorf("%w: section %d has %d trailing bytes", ErrSectionSizeMismatch, secId, sec.Remaining())
+			}
+			prevOrder = order
 		}
 	}
 
+	if len(module.CodeSec) != len(module.FuncSec) {
+		return fmt.Errorf("%d function bodies for %d functions", len(module.CodeSec), len(module.FuncSec))
+	}
+	if module.DataCountSec != nil && int64(*module.DataCountSec) != int64(len(module.DataSec)) {
+		return fmt.Errorf("data count %d does not match %d data segments", *module.DataCountSec, len(module.DataSec))
+	}
 	return nil
 }
 
+// lastSectionID returns the id of the last non-custom section decoded so far.
+func (module *Module) lastSectionID() byte {
+	for i := len(module.Sections) - 1; i >= 0; i-- {
+		if module.Sections[i].ID != SecCustomID {
+			return module.Sections[i].ID
+		}
+	}
+	return SecCustomID
+}
+
 func (module *Module) decodeNonSection(secId byte, bs *common.SliceBytes) error {
+	if err := module.checkSectionFeature(secId, bs.Offset()); err != nil {
+		return err
+	}
 	// 解析非自定义段
 	switch secId {
 	case SecTypeID:
@@ -220,6 +505,10 @@ func (module *Module) decodeNonSection(secId byte, bs *common.SliceBytes) error
 		return module.decodeCodeSection(bs)
 	case SecDataID:
 		return module.decodeDataSection(bs)
+	case SecDataCountID:
+		return module.decodeDataCountSection(bs)
+	case SecTagID:
+		return module.decodeTagSection(bs)
 	default:
 		return errors.New("invalid section id")
 	}
@@ -229,47 +518,208 @@ func (module *Module) decodeCustomSection(bs *common.SliceBytes) error {
 	customSec := CustomSec{}
 
 	// read byte_count
-	sectionLen, _, err := common.DecodeUint32(bs)
+	sectionLen, err := decodeSize(bs, module.opts.MaxCustomSectionSize, "custom section")
+	if err != nil {
+		return err
+	}
+	header := SectionHeader{ID: SecCustomID, Offset: bs.Offset(), Size: sectionLen}
+	sec, err := bs.ReadSlice(sectionLen)
 	if err != nil {
 		return err
 	}
 
 	// read name
-	var readLen int
-
-	if customSec.Name, readLen, err = bs.ReadName(); err != nil {
+	if customSec.Name, _, err = sec.ReadName(); err != nil {
 		return err
 	}
+	header.Name = customSec.Name
+	module.Sections = append(module.Sections, header)
 
 	// read bytes
-	num := int(sectionLen) - readLen
-	if customSec.Bytes, err = bs.ReadByteN(num); err != nil {
+	if customSec.Bytes, err = sec.ReadByteN(sec.Remaining()); err != nil {
 		return err
 	}
 
+	customSec.After = module.lastSectionID()
+
+	// like other engines, keep a custom section that fails to decode as
+	// raw bytes rather than rejecting the module over metadata
+	if decoder, ok := module.opts.CustomSections.Lookup(customSec.Name); ok {
+		customSec.Value, customSec.Err = decodeCustomSection(decoder, customSec.Name, customSec.Bytes)
+		if customSec.Err != nil {
+			customSec.Value = nil
+		}
+	}
+	switch customSec.Name {
+	case NameSectionName:
+		// a module has at most one name section, later ones are kept only
+		// as raw custom sections
+		if module.GetCustomSection(NameSectionName) == nil {
+			module.Names, _ = customSec.Value.(*NameSec)
+		}
+	case ProducersSectionName:
+		module.Producers, _ = customSec.Value.(*ProducersSec)
+	case TargetFeaturesSectionName:
+		module.TargetFeatures, _ = customSec.Value.(*TargetFeaturesSec)
+	}
+
 	module.CustomSecs = append(module.CustomSecs, customSec)
 	return nil
 }
 
 // decode Type Section
 func (module *Module) decodeTypeSection(bs *common.SliceBytes) error {
-	typeCount, _, err := common.DecodeInt32(bs)
+	typeCount, err := decodeCount(bs, module.opts.MaxTypes, "type")
 	if err != nil {
 		return err
 	}
 
-	module.TypeSec = make([]*common.FuncType, 0, typeCount)
+	module.TypeSec = make([]*common.SubType, 0, typeCount)
 
-	for i := int32(0); i < typeCount; i++ {
-		funcType, err := decodeFuncType(bs)
+	for i := uint32(0); i < typeCount; i++ {
+		if err = module.decodeRecType(bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRecType decodes a recursion group, which is either `rec` followed
+// by its subtypes or a single subtype forming a group of its own.
+func (module *Module) decodeRecType(bs *common.SliceBytes) error {
+	group := common.TypeIdx(len(module.TypeSec))
+	count := uint32(1)
+	if b, err := bs.PeekByte(); err == nil && b == common.TagRecType {
+		if err = module.requireFeature(common.FeatureGC, bs.Offset()); err != nil {
+			return err
+		}
+		_, _ = bs.ReadByte()
+		if count, err = decodeVecCount(bs, "recursion group type"); err != nil {
+			return err
+		}
+	}
+	if int64(len(module.TypeSec))+int64(count) > int64(module.opts.MaxTypes) {
+		return fmt.Errorf("%w: type count > %d", ErrLimitExceeded, module.opts.MaxTypes)
+	}
+
+	for i := uint32(0); i < count; i++ {
+		offset := bs.Offset()
+		if b, err := bs.PeekByte(); err == nil && (b == common.TagSubType || b == common.TagSubFinalType) {
+			if err = module.requireFeature(common.FeatureGC, offset); err != nil {
+				return err
+			}
+		}
+		subType, err := decodeSubType(bs)
 		if err != nil {
 			return err
 		}
-		module.TypeSec = append(module.TypeSec, funcType)
+		if err = module.checkSubType(subType, offset); err != nil {
+			return err
+		}
+		// supertypes must be defined before their subtypes
+		for _, super := range subType.SuperTypes {
+			if int64(super) >= int64(len(module.TypeSec)) {
+				return fmt.Errorf("%w: decodeTypeSection unknown supertype %d", ErrInvalid, super)
+			}
+		}
+		subType.RecGroup = group
+		module.TypeSec = append(module.TypeSec, subType)
 	}
 	return nil
 }
 
+func decodeSubType(bs *common.SliceBytes) (*common.SubType, error) {
+	subType := &common.SubType{Final: true}
+	tag, err := bs.PeekByte()
+	if err != nil {
+		return nil, fmt.Errorf("decodeTypeSection type failed %s", err.Error())
+	}
+	if tag == common.TagSubType || tag == common.TagSubFinalType {
+		_, _ = bs.ReadByte()
+		subType.Final = tag == common.TagSubFinalType
+		count, err := decodeVecCount(bs, "supertype")
+		if err != nil {
+			return nil, err
+		}
+		if count > 1 {
+			return nil, fmt.Errorf("decodeTypeSection %d supertypes, at most one is allowed", count)
+		}
+		for i := uint32(0); i < count; i++ {
+			super, _, err := common.DecodeUint32(bs)
+			if err != nil {
+				return nil, err
+			}
+			subType.SuperTypes = append(subType.SuperTypes, super)
+		}
+	}
+
+	if subType.Comp, err = decodeCompType(bs); err != nil {
+		return nil, err
+	}
+	return subType, nil
+}
+
+func decodeCompType(bs *common.SliceBytes) (common.CompType, error) {
+	tag, err := bs.PeekByte()
+	if err != nil {
+		return common.CompType{}, fmt.Errorf("decodeTypeSection type failed %s", err.Error())
+	}
+	comp := common.CompType{Tag: tag}
+	switch tag {
+	case common.TagFuncType:
+		comp.Func, err = decodeFuncType(bs)
+	case common.TagStructType:
+		_, _ = bs.ReadByte()
+		var count uint32
+		if count, err = decodeVecCount(bs, "struct field"); err != nil {
+			return comp, err
+		}
+		comp.Fields = make([]common.FieldType, 0, count)
+		for i := uint32(0); i < count; i++ {
+			field, err := decodeFieldType(bs)
+			if err != nil {
+				return comp, err
+			}
+			comp.Fields = append(comp.Fields, field)
+		}
+	case common.TagArrayType:
+		_, _ = bs.ReadByte()
+		var field common.FieldType
+		field, err = decodeFieldType(bs)
+		comp.Fields = []common.FieldType{field}
+	default:
+		return comp, fmt.Errorf("decodeTypeSection invalid type %b", tag)
+	}
+	return comp, err
+}
+
+func decodeFieldType(bs *common.SliceBytes) (common.FieldType, error) {
+	var field common.FieldType
+	b, err := bs.PeekByte()
+	if err != nil {
+		return field, err
+	}
+	if vt := common.ValType(b); vt == common.StorageTypeI8 || vt == common.StorageTypeI16 {
+		_, _ = bs.ReadByte()
+		field.Type = vt
+	} else if field.Type, err = decodeValueType(bs); err != nil {
+		return field, err
+	}
+
+	mut, err := bs.ReadByte()
+	if err != nil {
+		return field, err
+	}
+	switch mut {
+	case common.NotMutable:
+	case common.Mutable:
+		field.Mutable = true
+	default:
+		return field, fmt.Errorf("decodeTypeSection invalid mutability 0x%02x", mut)
+	}
+	return field, nil
+}
+
 func decodeFuncType(bs *common.SliceBytes) (*common.FuncType, error) {
 	// read type
 	tagType, err := bs.ReadByte()
@@ -300,12 +750,12 @@ func decodeFuncType(bs *common.SliceBytes) (*common.FuncType, error) {
 }
 
 func decodeValueTypes(bs *common.SliceBytes) ([]common.ValType, error) {
-	num, _, err := common.DecodeInt32(bs)
+	num, err := decodeVecCount(bs, "value type")
 	if err != nil {
 		return nil, err
 	}
 	valTypes := make([]common.ValType, 0, num)
-	for i := int32(0); i < num; i++ {
+	for i := uint32(0); i < num; i++ {
 		valType, err := decodeValueType(bs)
 		if err != nil {
 			return nil, err
@@ -320,22 +770,63 @@ func decodeValueType(bs *common.SliceBytes) (common.ValType, error) {
 	if err != nil {
 		return 0, err
 	}
-	return common.ValType(tag), nil
+	vt := common.ValType(tag)
+	if vt == common.ValTypeRef || vt == common.ValTypeRefNull {
+		ht, err := decodeHeapType(bs)
+		if err != nil {
+			return 0, err
+		}
+		return common.RefType(ht, vt == common.ValTypeRefNull), nil
+	}
+	return vt, nil
+}
+
+// decodeRefType decodes a value type that must be a reference type.
+func decodeRefType(bs *common.SliceBytes) (common.ValType, error) {
+	vt, err := decodeValueType(bs)
+	if err != nil {
+		return 0, err
+	}
+	if !vt.IsRef() {
+		return 0, fmt.Errorf("invalid reference type 0x%02x", uint64(vt))
+	}
+	return vt, nil
+}
+
+func decodeHeapType(bs *common.SliceBytes) (common.HeapType, error) {
+	n, _, err := common.DecodeInt33AsInt64(bs)
+	if err != nil {
+		return 0, err
+	}
+	ht := common.HeapType(n)
+	if !ht.IsValid() {
+		return 0, fmt.Errorf("invalid heap type %d", n)
+	}
+	return ht, nil
 }
 
 // decode Import Section
 func (module *Module) decodeImportSection(bs *common.SliceBytes) error {
-	importCount, _, err := common.DecodeInt32(bs)
+	importCount, err := decodeVecCount(bs, "import")
 	if err != nil {
 		return err
 	}
 
 	module.ImportSec = make([]*Import, 0, importCount)
-	for i := int32(0); i < importCount; i++ {
+	for i := uint32(0); i < importCount; i++ {
+		offset := bs.Offset()
 		imp, err := decodeImport(bs)
 		if err != nil {
 			return err
 		}
+		if err = module.checkImport(imp, offset); err != nil {
+			return err
+		}
+		if imp.Desc.Tag == ImportTagTag {
+			if _, err = module.tagFuncType(imp.Desc.ExnTag); err != nil {
+				return err
+			}
+		}
 		module.ImportSec = append(module.ImportSec, imp)
 	}
 
@@ -394,6 +885,13 @@ func decodeImport(bs *common.SliceBytes) (*Import, error) {
 			return nil, err
 		}
 		imp.Desc.Global = globalType
+	case ImportTagTag:
+		// tag type
+		tagType, err := decodeTagType(bs)
+		if err != nil {
+			return nil, err
+		}
+		imp.Desc.ExnTag = tagType
 	default:
 		return nil, errors.New("invalid import tag")
 	}
@@ -410,21 +908,21 @@ func decodeTypeIdx(bs *common.SliceBytes) (common.TypeIdx, error) {
 }
 
 func decodeTableType(bs *common.SliceBytes) (*common.TableType, error) {
-	tableTag, err := bs.ReadByte()
+	elemType, err := decodeRefType(bs)
 	if err != nil {
-		return nil, err
-	}
-	if tableTag != TableTypeTag {
-		return nil, fmt.Errorf("decodeTableType failed invalid table type tag %b", tableTag)
+		return nil, fmt.Errorf("decodeTableType failed %w", err)
 	}
 
 	limit, err := decodeLimitsType(bs)
 	if err != nil {
 		return nil, err
 	}
+	if limit.Shared() || limit.Is64() {
+		return nil, errors.New("decodeTableType failed tables cannot be shared or 64-bit")
+	}
 
 	return &common.TableType{
-		Tag:       tableTag,
+		ElemType:  elemType,
 		LimitsRef: limit,
 	}, nil
 }
@@ -435,6 +933,14 @@ func decodeMemType(bs *common.SliceBytes) (*common.MemType, error) {
 		return nil, err
 	}
 
+	maxPages := uint64(maxMemPages)
+	if limit.Is64() {
+		maxPages = maxMemPages64
+	}
+	if limit.Min > maxPages || limit.HasMax() && limit.Max > maxPages {
+		return nil, fmt.Errorf("%w: memory size must be at most %d pages", ErrInvalid, maxPages)
+	}
+
 	return &common.MemType{
 		LimitsRef: limit,
 	}, nil
@@ -442,35 +948,39 @@ func decodeMemType(bs *common.SliceBytes) (*common.MemType, error) {
 
 func decodeLimitsType(bs *common.SliceBytes) (*common.Limits, error) {
 	tag, err := bs.ReadByte()
-	var minVal, maxVal uint32
 	if err != nil {
 		return nil, err
 	}
 
 	switch tag {
-	case common.LimitsFlagNoMax:
-		minVal, _, err = common.DecodeUint32(bs)
-		if err != nil {
-			return nil, err
-		}
-	case common.LimitsFlagHasMax:
-		minVal, _, err = common.DecodeUint32(bs)
-		if err != nil {
+	case common.LimitsFlagNoMax, common.LimitsFlagHasMax, common.LimitsFlagSharedHasMax,
+		common.LimitsFlagI64NoMax, common.LimitsFlagI64HasMax, common.LimitsFlagI64SharedHasMax:
+	default:
+		return nil, fmt.Errorf("decodeLimitsType failed invalid limits tag %b", tag)
+	}
+
+	limits := &common.Limits{Tag: tag}
+	if limits.Min, err = decodeLimit(bs, limits.Is64()); err != nil {
+		return nil, err
+	}
+	if limits.HasMax() {
+		if limits.Max, err = decodeLimit(bs, limits.Is64()); err != nil {
 			return nil, err
 		}
-		maxVal, _, err = common.DecodeUint32(bs)
-		if err != nil {
-			return nil, err
+		if limits.Min > limits.Max {
+			return nil, fmt.Errorf("%w: limits minimum %d is greater than maximum %d", ErrInvalid, limits.Min, limits.Max)
 		}
-	default:
-		return nil, fmt.Errorf("decodeLimitsType failed invalid limits tag %b", tag)
 	}
+	return limits, nil
+}
 
-	return &common.Limits{
-		Tag: tag,
-		Min: minVal,
-		Max: maxVal,
-	}, nil
+func decodeLimit(bs *common.SliceBytes, is64 bool) (uint64, error) {
+	if is64 {
+		n, _, err := common.DecodeUint64(bs)
+		return n, err
+	}
+	n, _, err := common.DecodeUint32(bs)
+	return uint64(n), err
 }
 
 func decodeGlobalType(bs *common.SliceBytes) (*common.GlobalType, error) {
@@ -490,31 +1000,35 @@ func decodeGlobalType(bs *common.SliceBytes) (*common.GlobalType, error) {
 	return globalType, nil
 }
 
+// decodeExpr reads a constant expression up to its terminating end. The
+// instructions are decoded rather than scanned for an end byte, since the
+// byte may just as well occur inside an immediate such as `i32.const 11`.
 func decodeExpr(bs *common.SliceBytes) (*common.Expr, error) {
-	data := make([]byte, 0)
+	offset := bs.Offset()
+	length := 0
 	for {
-		op, err := bs.ReadByte()
+		instr, err := decodeInstruction(bs, 0)
 		if err != nil {
 			return nil, err
 		}
-		if op == common.ExprEnd {
+		if instr.Opcode == common.ExprEnd {
 			break
 		}
-		data = append(data, op)
+		length = bs.Offset() - offset
 	}
 
-	return &common.Expr{Data: data}, nil
+	return &common.Expr{Data: bs.Bytes(offset, length), Offset: offset}, nil
 }
 
 // decode Function Section
 func (module *Module) decodeFunctionSection(bs *common.SliceBytes) error {
-	funcCount, _, err := common.DecodeInt32(bs)
+	funcCount, err := decodeCount(bs, module.opts.MaxFunctions, "function")
 	if err != nil {
 		return err
 	}
 
 	module.FuncSec = make([]common.TypeIdx, 0, funcCount)
-	for i := int32(0); i < funcCount; i++ {
+	for i := uint32(0); i < funcCount; i++ {
 		typeIdx, _, err := common.DecodeInt32(bs)
 		if err != nil {
 			return err
@@ -527,44 +1041,73 @@ func (module *Module) decodeFunctionSection(bs *common.SliceBytes) error {
 
 // decode Table Section
 func (module *Module) decodeTableSection(bs *common.SliceBytes) error {
-	tableCount, _, err := common.DecodeInt32(bs)
+	tableCount, err := decodeVecCount(bs, "table")
 	if err != nil {
 		return err
 	}
 
-	if tableCount != 1 {
-		return fmt.Errorf("decodeTableSection failed invalid table count %d", tableCount)
-	}
-
 	module.TableSec = make([]common.TableType, 0, tableCount)
-	for i := int32(0); i < tableCount; i++ {
-		tableType, err := decodeTableType(bs)
+	for i := uint32(0); i < tableCount; i++ {
+		offset := bs.Offset()
+		tableType, err := decodeTable(bs)
 		if err != nil {
 			return err
 		}
+		if err = module.checkTable(tableType, offset); err != nil {
+			return err
+		}
 		module.TableSec = append(module.TableSec, *tableType)
 	}
 
 	return nil
 }
 
+// decodeTable decodes a table of the table section, which is either a table
+// type, or 0x40 0x00 followed by a table type and an init expression. Only
+// tables with a defaultable element type can leave out the expression.
+func decodeTable(bs *common.SliceBytes) (*common.TableType, error) {
+	if b, err := bs.PeekByte(); err != nil || b != tableFlagInit {
+		tableType, err := decodeTableType(bs)
+		if err == nil && !tableType.ElemType.Nullable() {
+			err = fmt.Errorf("%w: decodeTable failed table of non-nullable references needs an init expression", ErrInvalid)
+		}
+		return tableType, err
+	}
+
+	flags, err := bs.ReadByteN(2)
+	if err != nil {
+		return nil, err
+	}
+	if flags[1] != 0x00 {
+		return nil, fmt.Errorf("decodeTable failed invalid reserved byte 0x%02x", flags[1])
+	}
+	tableType, err := decodeTableType(bs)
+	if err != nil {
+		return nil, err
+	}
+	if tableType.Init, err = decodeExpr(bs); err != nil {
+		return nil, err
+	}
+	return tableType, nil
+}
+
 // decode Memory Section
 func (module *Module) decodeMemorySection(bs *common.SliceBytes) error {
-	memoryCount, _, err := common.DecodeInt32(bs)
+	memoryCount, err := decodeVecCount(bs, "memory")
 	if err != nil {
 		return err
 	}
 
-	if memoryCount != 1 {
-		return fmt.Errorf("decodeMemorySection failed invalid memory count %d", memoryCount)
-	}
-
 	module.MemSec = make([]common.MemType, 0, memoryCount)
-	for i := int32(0); i < memoryCount; i++ {
+	for i := uint32(0); i < memoryCount; i++ {
+		offset := bs.Offset()
 		memType, err := decodeMemType(bs)
 		if err != nil {
 			return err
 		}
+		if err = module.checkMemory(memType, offset); err != nil {
+			return err
+		}
 		module.MemSec = append(module.MemSec, *memType)
 	}
 
@@ -573,13 +1116,14 @@ func (module *Module) decodeMemorySection(bs *common.SliceBytes) error {
 
 // decode Global Section
 func (module *Module) decodeGlobalSection(bs *common.SliceBytes) error {
-	globalCount, _, err := common.DecodeInt32(bs)
+	globalCount, err := decodeVecCount(bs, "global")
 	if err != nil {
 		return err
 	}
 
 	module.GlobalSec = make([]*Global, 0, globalCount)
-	for i := int32(0); i < globalCount; i++ {
+	for i := uint32(0); i < globalCount; i++ {
+		offset := bs.Offset()
 		globalType, err := decodeGlobalType(bs)
 		if err != nil {
 			return err
@@ -590,10 +1134,14 @@ func (module *Module) decodeGlobalSection(bs *common.SliceBytes) error {
 			return err
 		}
 
-		module.GlobalSec = append(module.GlobalSec, &Global{
+		global := &Global{
 			Type: globalType,
 			Init: initExpr,
-		})
+		}
+		if err = module.checkGlobal(global, offset); err != nil {
+			return err
+		}
+		module.GlobalSec = append(module.GlobalSec, global)
 	}
 
 	return nil
@@ -601,18 +1149,22 @@ func (module *Module) decodeGlobalSection(bs *common.SliceBytes) error {
 
 // decode Export Section
 func (module *Module) decodeExportSection(bs *common.SliceBytes) error {
-	exportCount, _, err := common.DecodeInt32(bs)
+	exportCount, err := decodeVecCount(bs, "export")
 	if err != nil {
 		return err
 	}
 
 	module.ExportSec = make([]*Export, 0, exportCount)
 
-	for i := int32(0); i < exportCount; i++ {
+	for i := uint32(0); i < exportCount; i++ {
+		offset := bs.Offset()
 		export, err := decodeExport(bs)
 		if err != nil {
 			return err
 		}
+		if err = module.checkExport(export, offset); err != nil {
+			return err
+		}
 		module.ExportSec = append(module.ExportSec, export)
 	}
 
@@ -656,17 +1208,21 @@ func (module *Module) decodeStartSection(bs *common.SliceBytes) error {
 
 // decode Element Section
 func (module *Module) decodeElementSection(bs *common.SliceBytes) error {
-	elementCount, _, err := common.DecodeInt32(bs)
+	elementCount, err := decodeVecCount(bs, "element")
 	if err != nil {
 		return err
 	}
 
 	module.ElemSec = make([]*Elem, 0, elementCount)
-	for i := int32(0); i < elementCount; i++ {
+	for i := uint32(0); i < elementCount; i++ {
+		offset := bs.Offset()
 		elem, err := decodeElement(bs)
 		if err != nil {
 			return err
 		}
+		if err = module.checkElem(elem, offset); err != nil {
+			return err
+		}
 		module.ElemSec = append(module.ElemSec, elem)
 	}
 
@@ -674,27 +1230,74 @@ func (module *Module) decodeElementSection(bs *common.SliceBytes) error {
 }
 
 func decodeElement(bs *common.SliceBytes) (*Elem, error) {
-	elem := &Elem{}
+	elem := &Elem{Type: common.ValTypeFuncRef}
 
-	tableIdx, _, err := common.DecodeUint32(bs)
+	flags, _, err := common.DecodeUint32(bs)
 	if err != nil {
 		return nil, err
 	}
-	elem.Table = common.TableIdx(tableIdx)
+	if flags > 7 {
+		return nil, fmt.Errorf("decodeElement failed unsupported flags %d", flags)
+	}
 
-	offset, err := decodeExpr(bs)
-	if err != nil {
-		return nil, err
+	switch {
+	case flags&segmentFlagPassive == 0:
+		elem.Mode = SegmentModeActive
+		if flags&segmentFlagExplicitIdx != 0 {
+			if elem.Table, _, err = common.DecodeUint32(bs); err != nil {
+				return nil, err
+			}
+		}
+		if elem.Offset, err = decodeExpr(bs); err != nil {
+			return nil, err
+		}
+	case flags&segmentFlagExplicitIdx == 0:
+		elem.Mode = SegmentModePassive
+	default:
+		elem.Mode = SegmentModeDeclarative
+	}
+
+	// flags 0 and 4 imply funcref, the others spell out the elemkind, or
+	// the reftype if init is given as expressions
+	switch {
+	case flags&^segmentFlagExprs == 0:
+	case flags&segmentFlagExprs != 0:
+		if elem.Type, err = decodeRefType(bs); err != nil {
+			return nil, fmt.Errorf("decodeElement failed %w", err)
+		}
+	default:
+		kind, err := bs.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if kind != ElemKindFuncRef {
+			return nil, fmt.Errorf("decodeElement failed invalid elemkind 0x%02x", kind)
+		}
+	}
+
+	if flags&segmentFlagExprs != 0 {
+		exprCount, err := decodeVecCount(bs, "element expression")
+		if err != nil {
+			return nil, err
+		}
+		elem.Exprs = make([]*common.Expr, 0, exprCount)
+		for i := uint32(0); i < exprCount; i++ {
+			expr, err := decodeExpr(bs)
+			if err != nil {
+				return nil, err
+			}
+			elem.Exprs = append(elem.Exprs, expr)
+		}
+		return elem, nil
 	}
-	elem.Offset = offset
 
-	funcCount, _, err := common.DecodeInt32(bs)
+	funcCount, err := decodeVecCount(bs, "element function")
 	if err != nil {
 		return nil, err
 	}
 
 	elem.Init = make([]common.FuncIdx, 0, funcCount)
-	for i := int32(0); i < funcCount; i++ {
+	for i := uint32(0); i < funcCount; i++ {
 		funcIdx, _, err := common.DecodeUint32(bs)
 		if err != nil {
 			return nil, err
@@ -707,120 +1310,212 @@ func decodeElement(bs *common.SliceBytes) (*Elem, error) {
 
 // decode Code Section
 func (module *Module) decodeCodeSection(bs *common.SliceBytes) error {
-	codeCount, _, err := common.DecodeInt32(bs)
+	codeCount, err := decodeCount(bs, module.opts.MaxFunctions, "code")
 	if err != nil {
 		return err
 	}
 
+	if int64(codeCount) != int64(len(module.FuncSec)) {
+		return fmt.Errorf("%d function bodies for %d functions", codeCount, len(module.FuncSec))
+	}
+
 	module.CodeSec = make([]*Code, 0, codeCount)
-	for i := int32(0); i < codeCount; i++ {
-		code, err := decodeCode(bs)
+	for i := uint32(0); i < codeCount; i++ {
+		code, err := module.decodeCode(bs)
 		if err != nil {
 			return err
 		}
 		module.CodeSec = append(module.CodeSec, code)
+	}
+	if module.opts.LazyCode {
+		return nil
+	}
+	return module.decodeBodies()
+}
+
+// a code section smaller than this in bodies or bytes is decoded on one
+// goroutine
+const (
+	parallelCodeMinBodies = 64
+	parallelCodeMinBytes  = 64 << 10
+)
+
+// decodeBodies decodes every body of the code section across
+// opts.CodeWorkers goroutines. Bodies are independent once split, so only
+// the reported error depends on the order: it is the one of the lowest
+// failing body, as when decoding one after another.
+func (module *Module) decodeBodies() error {
+	n := len(module.CodeSec)
+	workers := module.opts.CodeWorkers
+	if workers > n {
+		workers = n
+	}
+	size := uint64(0)
+	for _, code := range module.CodeSec {
+		size += uint64(code.Size)
+	}
+	if n < parallelCodeMinBodies || size < parallelCodeMinBytes {
+		workers = 1
+	}
+	if workers <= 1 {
+		for i := range module.CodeSec {
+			if _, err := module.DecodeCode(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, n)
+	next := int64(-1)
+	failed := int64(n) // lowest failing body so far; later ones are skipped
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := atomic.AddInt64(&next, 1)
+				if i >= atomic.LoadInt64(&failed) {
+					return
+				}
+				if _, errs[i] = module.DecodeCode(int(i)); errs[i] == nil {
+					continue
+				}
+				for f := atomic.LoadInt64(&failed); i < f; f = atomic.LoadInt64(&failed) {
+					if atomic.CompareAndSwapInt64(&failed, f, i) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
 
+	if failed < int64(n) {
+		return errs[failed]
 	}
 	return nil
 }
 
-func decodeCode(bs *common.SliceBytes) (*Code, error) {
+// decodeCode splits off the next body by its size; DecodeCode decodes it.
+func (module *Module) decodeCode(bs *common.SliceBytes) (*Code, error) {
 	// decode byte_count
-	ss, _, err := common.DecodeUint32(bs)
+	ss, err := decodeSize(bs, module.opts.MaxBodySize, "function body")
 	if err != nil {
-		if err != nil {
-			return nil, fmt.Errorf("get the size of code segment: %d err %w", ss, err)
-		}
+		return nil, fmt.Errorf("get the size of code segment: %w", err)
+	}
+	body, err := bs.ReadSlice(ss)
+	if err != nil {
+		return nil, err
 	}
 
-	code := &Code{}
+	return &Code{Offset: body.Offset(), Size: ss, body: body}, nil
+}
 
+// decodeBody decodes the locals and the expression of code, the idx-th
+// body of the code section, from body and checks them.
+func (module *Module) decodeBody(idx int, code *Code, body *common.SliceBytes) error {
 	// locals
-	var localSize int
-	localCount, size, err := common.DecodeUint32(bs)
+	localCount, err := decodeVecCount(body, "locals")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	localSize += size
 
+	var total uint64
 	locals := make([]Locals, 0, localCount)
 	for i := uint32(0); i < localCount; i++ {
-		n, size, err := common.DecodeUint32(bs)
+		n, _, err := common.DecodeUint32(body)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		total += uint64(n)
+		if total > uint64(module.opts.MaxLocals) {
+			return fmt.Errorf("%w: local count %d > %d", ErrLimitExceeded, total, module.opts.MaxLocals)
 		}
-		localSize += size
 
-		valType, err := decodeValueType(bs)
+		valType, err := decodeValueType(body)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		localSize += 1
 
 		locals = append(locals, Locals{N: n, Type: valType})
 	}
 	code.Locals = locals
 
 	// expr
-	exprLen := int(ss) - localSize
-	if exprLen < 0 {
-		return nil, fmt.Errorf("invalid expr len %d", exprLen)
-	}
-
-	exprData, err := bs.ReadByteN(exprLen)
+	exprOffset := body.Offset()
+	exprData, err := body.ReadByteN(body.Remaining())
 	if err != nil {
-		return code, err
+		return err
 	}
 	code.Expr = &common.Expr{
-		Data: exprData,
+		Data:   exprData,
+		Offset: exprOffset,
 	}
 
-	return code, nil
+	return module.checkCode(idx, code)
 }
 
 // decode Data Section
 func (module *Module) decodeDataSection(bs *common.SliceBytes) error {
 	// decode data account
-	dataCount, _, err := common.DecodeInt32(bs)
+	dataCount, err := decodeVecCount(bs, "data")
 	if err != nil {
 		return err
 	}
 
 	module.DataSec = make([]*Data, 0, dataCount)
-	for i := int32(0); i < dataCount; i++ {
-		data, err := decodeData(bs)
+	for i := uint32(0); i < dataCount; i++ {
+		offset := bs.Offset()
+		data, err := decodeData(bs, &module.opts)
 		if err != nil {
 			return err
 		}
+		if err = module.checkData(data, offset); err != nil {
+			return err
+		}
 		module.DataSec = append(module.DataSec, data)
 	}
 
 	return nil
 }
 
-func decodeData(bs *common.SliceBytes) (*Data, error) {
+func decodeData(bs *common.SliceBytes, opts *DecodeOptions) (*Data, error) {
 	data := &Data{}
 
-	// index
-	memoryIdx, _, err := common.DecodeUint32(bs)
+	// flags: 0 active memory 0, 1 passive, 2 active with memory index
+	flags, _, err := common.DecodeUint32(bs)
 	if err != nil {
 		return nil, err
 	}
-	data.Mem = common.MemIdx(memoryIdx)
+	if flags > 2 {
+		return nil, fmt.Errorf("decodeData failed unsupported flags %d", flags)
+	}
 
-	// offset
-	offset, err := decodeExpr(bs)
-	if err != nil {
-		return nil, err
+	if flags&segmentFlagPassive != 0 {
+		data.Mode = SegmentModePassive
+	} else {
+		data.Mode = SegmentModeActive
+		// index
+		if flags&segmentFlagExplicitIdx != 0 {
+			if data.Mem, _, err = common.DecodeUint32(bs); err != nil {
+				return nil, err
+			}
+		}
+
+		// offset
+		if data.Offset, err = decodeExpr(bs); err != nil {
+			return nil, err
+		}
 	}
-	data.Offset = offset
 
 	// num_element
-	initLen, _, err := common.DecodeUint32(bs)
+	initLen, err := decodeSize(bs, opts.MaxDataSize, "data segment")
 	if err != nil {
 		return nil, err
 	}
-	initData, err := bs.ReadByteN(int(initLen))
+	initData, err := bs.ReadByteN(initLen)
 	if err != nil {
 		return nil, err
 	}
@@ -828,3 +1523,53 @@ func decodeData(bs *common.SliceBytes) (*Data, error) {
 
 	return data, nil
 }
+
+// decode Data Count Section
+func (module *Module) decodeDataCountSection(bs *common.SliceBytes) error {
+	count, _, err := common.DecodeUint32(bs)
+	if err != nil {
+		return err
+	}
+
+	module.DataCountSec = &count
+	return nil
+}
+
+// decode Tag Section
+func (module *Module) decodeTagSection(bs *common.SliceBytes) error {
+	tagCount, err := decodeVecCount(bs, "tag")
+	if err != nil {
+		return err
+	}
+
+	module.TagSec = make([]common.TagType, 0, tagCount)
+	for i := uint32(0); i < tagCount; i++ {
+		tagType, err := decodeTagType(bs)
+		if err != nil {
+			return err
+		}
+		if _, err = module.tagFuncType(tagType); err != nil {
+			return err
+		}
+		module.TagSec = append(module.TagSec, *tagType)
+	}
+
+	return nil
+}
+
+func decodeTagType(bs *common.SliceBytes) (*common.TagType, error) {
+	attribute, err := bs.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if attribute != common.TagAttributeException {
+		return nil, fmt.Errorf("invalid tag attribute 0x%02x", attribute)
+	}
+
+	typeIdx, _, err := common.DecodeUint32(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.TagType{Attribute: attribute, Type: typeIdx}, nil
+}
@@ -1,10 +1,13 @@
 package decode
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/luyiming112233/wasm/common"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"sync"
 	"testing"
 )
 
@@ -15,7 +18,7 @@ func TestModule(t *testing.T) {
 	sb := common.NewSliceBytes(buf)
 	module, err := DecodeModule(sb)
 	if module != nil {
-		fmt.Println(module.display())
+		fmt.Println(module.DisplayHeaders() + module.DisplayDetails())
 	}
 
 	assert.Nil(t, err)
@@ -23,3 +26,911 @@ func TestModule(t *testing.T) {
 	//data, err := json.Marshal(module)
 	//fmt.Println(string(data))
 }
+
+func TestDecodeModuleHostile(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		bytes []byte
+	}{
+		{name: "huge function count", bytes: []byte{0x03, 0x05, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F}},
+		{name: "huge data count", bytes: []byte{0x0B, 0x05, 0xFF, 0xFF, 0xFF, 0xFF, 0x07}},
+		{name: "section larger than input", bytes: []byte{0x01, 0xFF, 0xFF, 0x03, 0x00}},
+		{name: "custom name longer than section", bytes: []byte{0x00, 0x02, 0x7F, 0x61}},
+		{name: "trailing section bytes", bytes: []byte{0x03, 0x03, 0x01, 0x00, 0x00}},
+		{name: "duplicate section", bytes: []byte{0x03, 0x01, 0x00, 0x03, 0x01, 0x00}},
+		{name: "locals over limit", bytes: []byte{0x0A, 0x08, 0x01, 0x06, 0x01, 0xFF, 0xFF, 0x03, 0x7F, 0x0B}},
+		{name: "body larger than section", bytes: []byte{0x0A, 0x04, 0x01, 0xFF, 0xFF, 0x03}},
+		{name: "function without body", bytes: []byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00, 0x03, 0x02, 0x01, 0x00}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			buf := testModule(c.bytes)
+			assert.NotPanics(t, func() {
+				_, err := DecodeModule(common.NewSliceBytes(buf))
+				assert.Error(t, err)
+			})
+		})
+	}
+}
+
+func TestDecodeModuleOptions(t *testing.T) {
+	buf, err := ioutil.ReadFile("../testdata/wasm/ch01_hw.wasm")
+	assert.Nil(t, err)
+
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{MaxFunctions: 1})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{})
+	assert.Nil(t, err)
+}
+
+func TestNameSection(t *testing.T) {
+	buf, err := ioutil.ReadFile("../testdata/wasm/ch01_hw.wasm")
+	assert.Nil(t, err)
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	assert.Equal(t, "print_char", module.GetFuncName(0))
+	assert.Equal(t, "main", module.GetFuncName(1))
+
+	names, err := decodeNameSection([]byte{
+		0x00, 0x04, 0x03, 'm', 'o', 'd', // module name
+		0x02, 0x06, 0x01, 0x00, 0x01, 0x00, 0x01, 'x', // func 0, local 0 = x
+		0x07, 0x04, 0x01, 0x02, 0x01, 'g', // global 2 = g
+		0x7F, 0x01, 0x00, // unknown subsection
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "mod", names.ModuleName)
+	assert.Equal(t, "x", names.LocalNames[0][0])
+	assert.Equal(t, "g", names.GlobalNames[2])
+
+	_, err = decodeNameSection([]byte{0x01, 0x01, 0x00, 0x00, 0x01, 0x00})
+	assert.Error(t, err)
+
+	// only the first name section counts, and an empty module name is not shown
+	nameSec := func(sub ...byte) []byte {
+		return append([]byte{0x00, byte(len(sub) + 5), 0x04, 'n', 'a', 'm', 'e'}, sub...)
+	}
+	module, err = DecodeModule(common.NewSliceBytes(testModule(
+		nameSec(0x07, 0x04, 0x01, 0x00, 0x01, 'g'), // global 0 = g
+		nameSec(0x00, 0x04, 0x03, 'm', 'o', 'd'),   // module name
+	)))
+	assert.Nil(t, err)
+	assert.Equal(t, "", module.Names.ModuleName)
+	assert.Equal(t, "g", module.Names.GlobalNames[0])
+	assert.Len(t, module.CustomSecs, 2)
+	assert.Contains(t, module.DisplayDetails(), "Name:\n  global[0]: g\n")
+}
+
+func TestProducersSection(t *testing.T) {
+	producers, err := decodeProducersSection([]byte{
+		0x02,
+		0x08, 'l', 'a', 'n', 'g', 'u', 'a', 'g', 'e', 0x01, 0x04, 'R', 'u', 's', 't', 0x04, '1', '.', '7', '0',
+		0x03, 's', 'd', 'k', 0x01, 0x04, 'w', 'a', 's', 'i', 0x00,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []ProducerValue{{Name: "Rust", Version: "1.70"}}, producers.Language)
+	assert.Equal(t, []ProducerValue{{Name: "wasi", Version: ""}}, producers.SDK)
+
+	_, err = decodeProducersSection([]byte{0x02, 0x03, 's', 'd', 'k', 0x00, 0x03, 's', 'd', 'k', 0x00})
+	assert.Error(t, err)
+}
+
+func TestTargetFeaturesSection(t *testing.T) {
+	features, err := decodeTargetFeaturesSection([]byte{
+		0x03,
+		'+', 0x08, 's', 'i', 'g', 'n', '-', 'e', 'x', 't',
+		'-', 0x07, 's', 'i', 'm', 'd', '1', '2', '8',
+		'=', 0x07, 'a', 't', 'o', 'm', 'i', 'c', 's',
+	})
+	assert.Nil(t, err)
+	assert.Len(t, features.Features, 3)
+
+	err = features.Check(common.FeatureSignExtension)
+	assert.EqualError(t, err, "feature disabled: module requires features that are not enabled: atomics")
+	assert.Nil(t, features.Check(common.FeatureSignExtension|common.FeatureThreads))
+
+	// the names LLVM emits for a default wasm32 build
+	llvm := &TargetFeaturesSec{}
+	for _, name := range []string{"bulk-memory", "bulk-memory-opt", "call-indirect-overlong",
+		"multivalue", "mutable-globals", "nontrapping-fptoint", "reference-types", "sign-ext", "simd128"} {
+		llvm.Features = append(llvm.Features, TargetFeature{Prefix: TargetFeatureUsed, Name: name})
+	}
+	assert.Nil(t, llvm.Check(common.Features20))
+	err = llvm.Check(common.FeaturesMVP | common.FeatureMutableGlobals | common.FeatureSignExtension)
+	assert.EqualError(t, err, "feature disabled: module requires features that are not enabled: "+
+		"bulk-memory, bulk-memory-opt, call-indirect-overlong, multivalue, nontrapping-fptoint, reference-types, simd128")
+
+	module := &Module{TargetFeatures: llvm, opts: DefaultDecodeOptions()}
+	assert.Nil(t, module.CheckTargetFeatures())
+	module.opts.Features = common.FeaturesMVP
+	assert.Error(t, module.CheckTargetFeatures())
+	assert.Nil(t, (&Module{}).CheckTargetFeatures())
+
+	// decoding only refuses the module when asked to
+	buf := testModule(append([]byte{0x00, 0x1A, 0x0F}, append([]byte("target_features"),
+		0x01, '+', 0x07, 's', 'i', 'm', 'd', '1', '2', '8')...))
+	opts := DecodeOptions{Features: common.FeaturesMVP}
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf), opts)
+	assert.Nil(t, err)
+	opts.CheckTargetFeatures = true
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf), opts)
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	assert.ErrorContains(t, err, "not enabled: simd128")
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{CheckTargetFeatures: true})
+	assert.Nil(t, err)
+
+	unknown := &TargetFeaturesSec{Features: []TargetFeature{
+		{Prefix: TargetFeatureUsed, Name: "frobnicate"}, {Prefix: TargetFeatureUsed, Name: "shared-mem"}}}
+	assert.Nil(t, unknown.Check(common.FeatureThreads))
+	assert.EqualError(t, unknown.Check(common.FeaturesMVP), "feature disabled: module requires features that are not enabled: shared-mem")
+
+	_, err = decodeTargetFeaturesSection([]byte{0x01, '?', 0x01, 'x'})
+	assert.Error(t, err)
+}
+
+func TestCustomSectionRegistry(t *testing.T) {
+	buf := testModule(
+		[]byte{0x00, 0x05, 0x03, 'f', 'o', 'o', 0x2A}, // foo before any section
+		[]byte{SecTypeID, 0x01, 0x00},
+		[]byte{0x00, 0x04, 0x03, 'b', 'a', 'r'},            // bar after the type section
+		[]byte{0x00, 0x06, 0x04, 'n', 'a', 'm', 'e', 0x05}, // malformed name section
+		[]byte{0x00, 0x04, 0x03, 'b', 'a', 'z'},            // baz, whose decoder panics
+	)
+
+	registry := NewCustomSectionRegistry()
+	registry.Register("foo", func(name string, data []byte) (any, error) {
+		return int(data[0]), nil
+	})
+	registry.Register("bar", func(name string, data []byte) (any, error) {
+		return nil, errors.New("bad bar")
+	})
+	registry.Register("baz", func(name string, data []byte) (any, error) {
+		return data[0], nil
+	})
+	module, err := DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{CustomSections: registry})
+	assert.Nil(t, err)
+
+	foo := module.GetCustomSection("foo")
+	assert.Equal(t, 42, foo.Value)
+	assert.Equal(t, byte(SecCustomID), foo.After)
+
+	bar := module.GetCustomSection("bar")
+	assert.Nil(t, bar.Value)
+	assert.EqualError(t, bar.Err, "bad bar")
+	assert.Equal(t, byte(SecTypeID), bar.After)
+
+	assert.Nil(t, module.Names)
+	assert.Error(t, module.GetCustomSection(NameSectionName).Err)
+	assert.Nil(t, module.GetCustomSection("missing"))
+
+	baz := module.GetCustomSection("baz")
+	assert.Nil(t, baz.Value)
+	assert.ErrorContains(t, baz.Err, `custom section "baz" decoder panicked: runtime error: index out of range`)
+}
+
+func TestBulkMemory(t *testing.T) {
+	buf := testModule(
+		[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00},                               // type ()->()
+		[]byte{0x03, 0x02, 0x01, 0x00},                                           // func 0
+		[]byte{0x04, 0x04, 0x01, 0x70, 0x00, 0x01},                               // table funcref 1
+		[]byte{0x05, 0x03, 0x01, 0x00, 0x01},                                     // memory 1
+		[]byte{0x09, 0x09, 0x02, 0x01, 0x00, 0x01, 0x00, 0x03, 0x00, 0x01, 0x00}, // passive and declarative elems
+		[]byte{0x0C, 0x01, 0x02},                                                 // data count 2
+		[]byte{0x0A, 0x1D, 0x01, 0x1B, 0x00,
+			0xFC, 0x08, 0x01, 0x00, // memory.init 1
+			0xFC, 0x09, 0x00, // data.drop 0
+			0xFC, 0x0A, 0x00, 0x00, // memory.copy
+			0xFC, 0x0B, 0x00, // memory.fill
+			0xFC, 0x0C, 0x00, 0x00, // table.init 0 0
+			0xFC, 0x0D, 0x00, // elem.drop 0
+			0xFC, 0x0E, 0x00, 0x00, // table.copy 0 0
+			0x0B,
+		},
+		[]byte{0x0B, 0x0C, 0x02, 0x01, 0x02, 'h', 'i', 0x02, 0x00, 0x41, 0x00, 0x0B, 0x01, 'x'}, // passive and active data
+	)
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) {
+		return
+	}
+
+	assert.Equal(t, uint32(2), *module.DataCountSec)
+	assert.Equal(t, byte(SegmentModePassive), module.ElemSec[0].Mode)
+	assert.Equal(t, byte(SegmentModeDeclarative), module.ElemSec[1].Mode)
+	assert.Equal(t, byte(SegmentModePassive), module.DataSec[0].Mode)
+	assert.Equal(t, []byte("hi"), module.DataSec[0].Init)
+	assert.Equal(t, byte(SegmentModeActive), module.DataSec[1].Mode)
+	assert.NotNil(t, module.DataSec[1].Offset)
+
+	instrs, err := DecodeInstructions(module.CodeSec[0].Expr)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"memory.init 1", "data.drop 0", "memory.copy", "memory.fill",
+		"table.init 0 0", "elem.drop 0", "table.copy 0 0", "end"}, instrStrings(instrs))
+	assert.Contains(t, module.DisplayHeaders(), "DataCount")
+	assert.Contains(t, module.DisplayDetails(), "elem[1]: declarative, init=[0]")
+
+	for _, c := range []struct {
+		name  string
+		bytes []byte
+	}{
+		{name: "data count mismatch", bytes: []byte{0x0C, 0x01, 0x01}},
+		{name: "data count after code", bytes: []byte{0x0A, 0x01, 0x00, 0x0C, 0x01, 0x00}},
+		{name: "invalid elemkind", bytes: []byte{0x09, 0x04, 0x01, 0x01, 0x01, 0x00}},
+		{name: "invalid data flags", bytes: []byte{0x0B, 0x03, 0x01, 0x03, 0x00}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := DecodeModule(common.NewSliceBytes(testModule(c.bytes)))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTruncSat(t *testing.T) {
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0xFC, 0x00, 0xFC, 0x01, 0xFC, 0x02, 0xFC, 0x03,
+		0xFC, 0x04, 0xFC, 0x05, 0xFC, 0x06,
+		0xFC, 0x87, 0x00, // sub-opcode 7 in a two byte LEB128
+	}, Offset: 0x10})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"i32.trunc_sat_f32_s", "i32.trunc_sat_f32_u", "i32.trunc_sat_f64_s", "i32.trunc_sat_f64_u",
+		"i64.trunc_sat_f32_s", "i64.trunc_sat_f32_u", "i64.trunc_sat_f64_s", "i64.trunc_sat_f64_u",
+	}, instrStrings(instrs))
+	assert.Equal(t, 0x10+14, instrs[7].Offset)
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xFC, 0x12}})
+	assert.Error(t, err)
+}
+
+func TestReferenceTypes(t *testing.T) {
+	buf := testModule(
+		[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00},                   // type ()->()
+		[]byte{0x03, 0x02, 0x01, 0x00},                               // func 0
+		[]byte{0x04, 0x07, 0x02, 0x70, 0x00, 0x01, 0x6F, 0x00, 0x00}, // funcref and externref tables
+		[]byte{0x09, 0x1F, 0x04,
+			0x04, 0x41, 0x00, 0x0B, 0x01, 0xD2, 0x00, 0x0B, // active, (ref.func 0)
+			0x05, 0x6F, 0x01, 0xD0, 0x6F, 0x0B, // passive externref, (ref.null extern)
+			0x06, 0x01, 0x41, 0x00, 0x0B, 0x70, 0x01, 0xD0, 0x70, 0x0B, // active table 1, (ref.null func)
+			0x07, 0x70, 0x01, 0xD2, 0x00, 0x0B, // declarative, (ref.func 0)
+		},
+		[]byte{0x0A, 0x19, 0x01, 0x17, 0x00,
+			0xD2, 0x00, // ref.func 0
+			0xD1,       // ref.is_null
+			0x25, 0x01, // table.get 1
+			0x26, 0x00, // table.set 0
+			0xFC, 0x0F, 0x00, // table.grow 0
+			0xFC, 0x10, 0x01, // table.size 1
+			0xFC, 0x11, 0x00, // table.fill 0
+			0x1C, 0x01, 0x7F, // select (result i32)
+			0xD0, 0x70, // ref.null func
+			0x0B,
+		},
+	)
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) {
+		return
+	}
+
+	assert.Len(t, module.TableSec, 2)
+	assert.Equal(t, common.ValTypeExternRef, module.TableSec[1].ElemType)
+	assert.Len(t, module.ElemSec, 4)
+	assert.Equal(t, common.ValTypeExternRef, module.ElemSec[1].Type)
+	assert.Equal(t, uint32(1), module.ElemSec[2].Table)
+	assert.Equal(t, byte(SegmentModeDeclarative), module.ElemSec[3].Mode)
+	assert.Contains(t, module.DisplayDetails(), "elem[1]: passive, type=externref, init=[[ref.null extern]]")
+
+	instrs, err := DecodeInstructions(module.CodeSec[0].Expr)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ref.func 0", "ref.is_null", "table.get 1", "table.set 0", "table.grow 0",
+		"table.size 1", "table.fill 0", "select (result i32)", "ref.null func", "end"}, instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xD0, 0x7F}})
+	assert.Error(t, err)
+}
+
+func TestBlockType(t *testing.T) {
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x02, 0x40, // block
+		0x03, 0x7E, // loop (result i64)
+		0x04, 0x00, // if (type 0)
+		0x02, 0x80, 0x01, // block (type 128)
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"block", "loop (result i64)", "if (type 0)", "block (type 128)"}, instrStrings(instrs))
+	assert.Equal(t, common.BlockTypeOf(common.ValTypeI64), instrs[1].BlockType)
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0x02, 0x60}})
+	assert.Error(t, err)
+}
+
+func TestSIMD(t *testing.T) {
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0xFD, 0x0C, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 4, 0, 0, 0, // v128.const
+		0xFD, 0x0D, 0, 1, 2, 3, 4, 5, 6, 7, 16, 17, 18, 19, 20, 21, 22, 23, // i8x16.shuffle
+		0xFD, 0x00, 0x04, 0x10, // v128.load
+		0xFD, 0x15, 0x03, // i8x16.extract_lane_s 3
+		0xFD, 0x56, 0x02, 0x00, 0x01, // v128.load32_lane 1
+		0xFD, 0xAE, 0x01, // i32x4.add
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"v128.const i32x4 0x00000001 0x00000002 0x00000003 0x00000004",
+		"i8x16.shuffle 0 1 2 3 4 5 6 7 16 17 18 19 20 21 22 23",
+		"v128.load offset=16 align=16",
+		"i8x16.extract_lane_s 3",
+		"v128.load32_lane align=4 1",
+		"i32x4.add",
+	}, instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xFD, 0x9A, 0x01}})
+	assert.Error(t, err)
+}
+
+func TestThreads(t *testing.T) {
+	module, err := DecodeModule(common.NewSliceBytes(testModule(
+		[]byte{0x05, 0x04, 0x01, 0x03, 0x01, 0x02}))) // shared memory 1..2
+	assert.Nil(t, err)
+	if assert.NotNil(t, module) {
+		assert.True(t, module.MemSec[0].LimitsRef.Shared())
+		assert.Contains(t, module.DisplayDetails(), "memory[0]: {min: 1, max: 2, shared}")
+	}
+
+	_, err = DecodeModule(common.NewSliceBytes(testModule(
+		[]byte{0x04, 0x05, 0x01, 0x70, 0x03, 0x00, 0x01}))) // shared table
+	assert.Error(t, err)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0xFE, 0x10, 0x02, 0x00, // i32.atomic.load
+		0xFE, 0x03, 0x00, // atomic.fence
+		0xFE, 0x00, 0x02, 0x08, // memory.atomic.notify offset=8
+		0xFE, 0x48, 0x02, 0x00, // i32.atomic.rmw.cmpxchg
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"i32.atomic.load align=4", "atomic.fence",
+		"memory.atomic.notify offset=8 align=4", "i32.atomic.rmw.cmpxchg align=4"}, instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xFE, 0x03, 0x01}})
+	assert.Error(t, err)
+}
+
+func TestMemory64(t *testing.T) {
+	module, err := DecodeModule(common.NewSliceBytes(testModule(
+		[]byte{0x05, 0x09, 0x01, 0x05, 0x01, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}))) // i64 memory 1..2^35
+	assert.Nil(t, err)
+	if assert.NotNil(t, module) {
+		limits := module.MemSec[0].LimitsRef
+		assert.True(t, limits.Is64())
+		assert.Equal(t, uint64(1), limits.Min)
+		assert.Equal(t, uint64(1)<<35, limits.Max)
+	}
+
+	_, err = DecodeModule(common.NewSliceBytes(testModule(
+		[]byte{0x05, 0x03, 0x01, 0x06, 0x01}))) // shared without max
+	assert.Error(t, err)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x28, 0x02, 0x80, 0x80, 0x80, 0x80, 0x10, // i32.load offset=2^32
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, "i32.load offset=4294967296 align=4", instrs[0].String())
+
+	for _, c := range []struct {
+		name string
+		mem  []byte
+	}{
+		{name: "min above max", mem: []byte{0x05, 0x04, 0x01, 0x01, 0x02, 0x01}},
+		{name: "65537 pages", mem: []byte{0x05, 0x05, 0x01, 0x00, 0x81, 0x80, 0x04}},
+		{name: "2^48+1 pages of memory64",
+			mem: []byte{0x05, 0x09, 0x01, 0x04, 0x81, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}},
+	} {
+		_, err = DecodeModule(common.NewSliceBytes(testModule(c.mem)))
+		assert.ErrorIs(t, err, ErrInvalid, c.name)
+	}
+	_, err = DecodeModule(common.NewSliceBytes(testModule(
+		[]byte{0x05, 0x09, 0x01, 0x04, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}))) // i64 memory of 2^48 pages
+	assert.Nil(t, err)
+
+	// an offset of 2^32 only fits a memory64 memory
+	withOffset := func(memTag byte) []byte {
+		return testModule(
+			[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00}, // type ()->()
+			[]byte{0x03, 0x02, 0x01, 0x00},             // func 0
+			[]byte{0x05, 0x03, 0x01, memTag, 0x01},     // memory 1
+			[]byte{0x0A, 0x0E, 0x01, 0x0C, 0x00,
+				0x42, 0x00, // i64.const 0
+				0x28, 0x02, 0x80, 0x80, 0x80, 0x80, 0x10, // i32.load offset=2^32
+				0x1A, 0x0B, // drop, end
+			})
+	}
+	_, err = DecodeModule(common.NewSliceBytes(withOffset(0x04)))
+	assert.Nil(t, err)
+	_, err = DecodeModule(common.NewSliceBytes(withOffset(0x00)))
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.ErrorContains(t, err, "offset 4294967296 is out of range for a 32-bit memory")
+}
+
+func TestMultiMemory(t *testing.T) {
+	buf := testModule(
+		[]byte{0x02, 0x0A, 0x01, 0x01, 'e', 0x03, 'm', 'e', 'm', 0x02, 0x00, 0x01}, // import env.mem 1
+		[]byte{0x05, 0x05, 0x02, 0x00, 0x02, 0x00, 0x03},                           // memories of 2 and 3 pages
+	)
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) {
+		return
+	}
+	for idx, min := range []uint64{1, 2, 3} {
+		memType, err := module.GetMemType(common.MemIdx(idx))
+		assert.Nil(t, err)
+		assert.Equal(t, min, memType.LimitsRef.Min)
+	}
+	_, err = module.GetMemType(3)
+	assert.Error(t, err)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x28, 0x42, 0x02, 0x04, // i32.load 2 offset=4
+		0x36, 0x02, 0x00, // i32.store
+		0x3F, 0x01, // memory.size 1
+		0xFC, 0x08, 0x00, 0x02, // memory.init 2 0
+		0xFC, 0x0A, 0x01, 0x02, // memory.copy 1 2
+		0xFC, 0x0A, 0x00, 0x00, // memory.copy
+		0xFC, 0x0B, 0x01, // memory.fill 1
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"i32.load 2 offset=4 align=4", "i32.store align=4", "memory.size 1",
+		"memory.init 2 0", "memory.copy 1 2", "memory.copy", "memory.fill 1"}, instrStrings(instrs))
+}
+
+func TestExceptionHandling(t *testing.T) {
+	types := []byte{0x01, 0x09, 0x02, 0x60, 0x01, 0x7F, 0x00, 0x60, 0x00, 0x01, 0x7F}     // (i32) -> () and () -> i32
+	imports := []byte{0x02, 0x0A, 0x01, 0x01, 'e', 0x03, 'e', 'r', 'r', 0x04, 0x00, 0x00} // import tag e.err
+	tags := []byte{0x0D, 0x03, 0x01, 0x00, 0x00}                                          // tag of type 0
+	exports := []byte{0x07, 0x05, 0x01, 0x01, 'x', 0x04, 0x01}                            // export tag 1
+	module, err := DecodeModule(common.NewSliceBytes(testModule(types, imports, tags, exports)))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) {
+		return
+	}
+	assert.Len(t, module.TagSec, 1)
+	for idx := uint32(0); idx < 2; idx++ {
+		tagType, err := module.GetTagType(idx)
+		assert.Nil(t, err)
+		assert.Equal(t, []common.ValType{common.ValTypeI32}, tagType.InputTypes)
+	}
+	_, err = module.GetTagType(2)
+	assert.Error(t, err)
+	assert.Contains(t, module.DisplayDetails(), "tag[0]: e.err, sig=0")
+	assert.Contains(t, module.DisplayDetails(), "tag[1]=x")
+
+	// tag section before the memory section
+	_, err = DecodeModule(common.NewSliceBytes(testModule(types, imports, tags, []byte{0x05, 0x03, 0x01, 0x00, 0x01})))
+	assert.Error(t, err)
+	// tag whose type has results
+	_, err = DecodeModule(common.NewSliceBytes(testModule(types, imports, []byte{0x0D, 0x03, 0x01, 0x00, 0x01})))
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.ErrorContains(t, err, "tag type 1 has results")
+	// tag of an unknown type
+	_, err = DecodeModule(common.NewSliceBytes(testModule(types, imports, []byte{0x0D, 0x03, 0x01, 0x00, 0x02})))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x1F, 0x7F, 0x02, 0x00, 0x00, 0x00, 0x03, 0x01, // try_table (result i32) (catch 0 0) (catch_all_ref 1)
+		0x08, 0x01, // throw 1
+		0x0B,       // end
+		0x0A,       // throw_ref
+		0xD0, 0x69, // ref.null exn
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"try_table (result i32) (catch 0 0) (catch_all_ref 1)", "throw 1", "end",
+		"throw_ref", "ref.null exn"}, instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0x1F, 0x40, 0x01, 0x04, 0x00}})
+	assert.Error(t, err)
+}
+
+func TestTailCall(t *testing.T) {
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x12, 0x03, // return_call 3
+		0x13, 0x01, 0x02, // return_call_indirect 2 (type 1)
+	}})
+	assert.Nil(t, err)
+	if assert.Len(t, instrs, 2) {
+		assert.Equal(t, "return_call 3", instrs[0].String())
+		assert.Equal(t, "return_call_indirect 2 (type 1)", instrs[1].String())
+	}
+}
+
+func TestTailCallResults(t *testing.T) {
+	// build returns a module of four functions, one of each type, where
+	// function caller has body as its instructions
+	build := func(caller int, body ...byte) []byte {
+		types := []byte{0x01, 0x11, 0x04,
+			0x60, 0x00, 0x01, 0x7F, // 0: () -> i32
+			0x60, 0x00, 0x00, // 1: () -> ()
+			0x60, 0x00, 0x01, 0x64, 0x00, // 2: () -> (ref 0)
+			0x60, 0x00, 0x01, 0x70, // 3: () -> funcref
+		}
+		funcs := []byte{0x03, 0x05, 0x04, 0x00, 0x01, 0x02, 0x03}
+		code := []byte{0x04}
+		for i := 0; i < 4; i++ {
+			if i == caller {
+				code = append(append(append(code, byte(len(body)+2), 0x00), body...), 0x0B)
+			} else {
+				code = append(code, 0x02, 0x00, 0x0B)
+			}
+		}
+		return testModule(types, funcs, append([]byte{0x0A, byte(len(code))}, code...))
+	}
+	for _, c := range []struct {
+		name   string
+		caller int
+		body   []byte
+		valid  bool
+	}{
+		{name: "same results", caller: 0, body: []byte{0x12, 0x00}, valid: true},
+		{name: "subtype results", caller: 3, body: []byte{0x12, 0x02}, valid: true},
+		{name: "supertype results", caller: 2, body: []byte{0x12, 0x03}},
+		{name: "fewer results", caller: 0, body: []byte{0x12, 0x01}},
+		{name: "indirect", caller: 0, body: []byte{0x13, 0x01, 0x00}},
+		{name: "by reference", caller: 1, body: []byte{0x15, 0x00}},
+		{name: "unknown function", caller: 0, body: []byte{0x12, 0x04}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := DecodeModule(common.NewSliceBytes(build(c.caller, c.body...)))
+			if c.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalid)
+			}
+		})
+	}
+
+	_, err := DecodeModule(common.NewSliceBytes(build(0, 0x12, 0x01)))
+	assert.ErrorContains(t, err, "return_call at 0x27: callee results () do not match function results (i32)")
+
+	// with every feature enabled, only bodies that may hold a tail call or
+	// a wide memarg offset are decoded to be checked
+	module := &Module{opts: DefaultDecodeOptions()}
+	assert.False(t, module.walkCode([]byte{0x41, 0x01, 0x1A, 0x0B}))
+	assert.True(t, module.walkCode([]byte{0x12, 0x00, 0x0B}))
+	assert.True(t, module.walkCode([]byte{0x41, 0x00, 0x28, 0x02, 0x80, 0x80, 0x80, 0x80, 0x10, 0x1A, 0x0B}))
+	module.opts.Features = common.FeaturesMVP
+	assert.True(t, module.walkCode([]byte{0x41, 0x01, 0x1A, 0x0B}))
+}
+
+func TestTypedFuncRefs(t *testing.T) {
+	types := []byte{0x01, 0x0B, 0x02, 0x60, 0x00, 0x00, 0x60, 0x01, 0x63, 0x00, 0x01, 0x64, 0x70} // () -> () and ((ref null 0)) -> (ref func)
+	buf := testModule(types, []byte{0x04, 0x0E, 0x02,
+		0x63, 0x00, 0x00, 0x01, // table (ref null 0)
+		0x40, 0x00, 0x64, 0x70, 0x00, 0x01, 0xD2, 0x00, 0x0B, // table (ref func) with init ref.func 0
+	})
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) {
+		return
+	}
+	ft, err := module.GetType(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []common.ValType{common.RefType(0, true)}, ft.InputTypes)
+	assert.Equal(t, []common.ValType{common.RefType(common.HeapTypeFunc, false)}, ft.ReturnTypes)
+	assert.Equal(t, common.RefType(0, true), module.TableSec[0].ElemType)
+	assert.Nil(t, module.TableSec[0].Init)
+	if assert.NotNil(t, module.TableSec[1].Init) {
+		assert.Equal(t, "[ref.func 0]", displayExpr(module.TableSec[1].Init))
+	}
+	assert.Contains(t, module.DisplayDetails(), "(ref null 0)")
+	assert.Contains(t, module.DisplayDetails(), "table[1]: (ref func)")
+
+	// non-nullable tables need an init expression
+	_, err = DecodeModule(common.NewSliceBytes(testModule(types, []byte{0x04, 0x05, 0x01, 0x64, 0x00, 0x00, 0x01})))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0x02, 0x64, 0x00, // block (result (ref 0))
+		0xD0, 0x00, // ref.null 0
+		0xD4,       // ref.as_non_null
+		0xD5, 0x00, // br_on_null 0
+		0xD6, 0x01, // br_on_non_null 1
+		0x14, 0x00, // call_ref (type 0)
+		0x15, 0x01, // return_call_ref (type 1)
+		0x0B, // end
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"block (result (ref 0))", "ref.null 0", "ref.as_non_null", "br_on_null 0",
+		"br_on_non_null 1", "call_ref (type 0)", "return_call_ref (type 1)", "end"}, instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xD0, 0x7F}})
+	assert.Error(t, err)
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0x02, 0xFF, 0x7E}})
+	assert.Error(t, err)
+}
+
+func TestGC(t *testing.T) {
+	types := []byte{
+		0x03,                               // 3 recursion groups
+		0x5F, 0x02, 0x7F, 0x01, 0x78, 0x00, // 0: struct {mut i32, i8}
+		0x4E, 0x02, // rec group of 2
+		0x50, 0x01, 0x00, 0x5F, 0x01, 0x64, 0x02, 0x00, // 1: sub 0 struct {(ref 2)}
+		0x5E, 0x77, 0x01, // 2: array {mut i16}
+		0x4F, 0x00, 0x60, 0x00, 0x00, // 3: sub final () -> ()
+	}
+	buf := testModule(append([]byte{0x01, byte(len(types))}, types...))
+	module, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, module) || !assert.Len(t, module.TypeSec, 4) {
+		return
+	}
+	assert.Equal(t, &common.SubType{Final: true, Comp: common.CompType{Tag: common.TagStructType, Fields: []common.FieldType{
+		{Type: common.ValTypeI32, Mutable: true}, {Type: common.StorageTypeI8},
+	}}}, module.TypeSec[0])
+	assert.False(t, module.TypeSec[1].Final)
+	assert.Equal(t, []common.TypeIdx{0}, module.TypeSec[1].SuperTypes)
+	assert.Equal(t, common.TypeIdx(1), module.TypeSec[2].RecGroup)
+	assert.Equal(t, common.TypeIdx(3), module.TypeSec[3].RecGroup)
+	_, err = module.GetType(0)
+	assert.Error(t, err)
+	_, err = module.GetType(3)
+	assert.Nil(t, err)
+	details := module.DisplayDetails()
+	assert.Contains(t, details, "type[0]: struct{mut i32, i8}\n")
+	assert.Contains(t, details, "type[1]: struct{(ref 2)} open <: 0 rec=1\n")
+	assert.Contains(t, details, "type[2]: array{mut i16} rec=1\n")
+
+	// a supertype must come before its subtypes
+	bad := append([]byte{}, buf...)
+	bad[len(buf)-len(types)+11] = 0x02
+	_, err = DecodeModule(common.NewSliceBytes(bad))
+	assert.ErrorContains(t, err, "unknown supertype 2")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	instrs, err := DecodeInstructions(&common.Expr{Data: []byte{
+		0xFB, 0x00, 0x00, // struct.new 0
+		0xFB, 0x03, 0x00, 0x01, // struct.get_s 0 1
+		0xFB, 0x08, 0x02, 0x03, // array.new_fixed 2 3
+		0xFB, 0x11, 0x02, 0x02, // array.copy 2 2
+		0xFB, 0x0F, // array.len
+		0xFB, 0x15, 0x6C, // ref.test i31ref
+		0xFB, 0x16, 0x00, // ref.cast (ref 0)
+		0xFB, 0x18, 0x01, 0x00, 0x6E, 0x01, // br_on_cast 0 anyref (ref 1)
+		0xFB, 0x1C, // ref.i31
+		0xD3, // ref.eq
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"struct.new 0", "struct.get_s 0 1", "array.new_fixed 2 3", "array.copy 2 2",
+		"array.len", "ref.test i31ref", "ref.cast (ref 0)", "br_on_cast 0 anyref (ref 1)", "ref.i31", "ref.eq"},
+		instrStrings(instrs))
+
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xFB, 0x18, 0x04, 0x00, 0x6E, 0x01}})
+	assert.Error(t, err)
+	_, err = DecodeInstructions(&common.Expr{Data: []byte{0xFB, 0x1F}})
+	assert.Error(t, err)
+}
+
+func TestFeatures(t *testing.T) {
+	// withBody returns a module of one ()->() function with body, its local
+	// declarations and instructions, at 0x16.
+	withBody := func(body ...byte) []byte {
+		return testModule(
+			[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00}, // type ()->()
+			[]byte{0x03, 0x02, 0x01, 0x00},             // func 0
+			append([]byte{0x0A, byte(len(body) + 2), 0x01, byte(len(body))}, body...))
+	}
+	for _, c := range []struct {
+		name    string
+		bytes   []byte
+		feature string
+		offset  int
+	}{
+		{name: "sign extension", feature: "sign-ext", offset: 0x19,
+			bytes: withBody(0x00, 0x41, 0x00, 0xC0, 0x1A, 0x0B)},
+		{name: "saturating truncation", feature: "nontrapping-fptoint", offset: 0x1C,
+			bytes: withBody(0x00, 0x43, 0x00, 0x00, 0x00, 0x00, 0xFC, 0x00, 0x1A, 0x0B)},
+		{name: "v128 local", feature: "simd128", offset: 0x16,
+			bytes: withBody(0x01, 0x01, 0x7B, 0x0B)},
+		{name: "memarg memory index 0", feature: "multimemory", offset: 0x19,
+			bytes: withBody(0x00, 0x41, 0x00, 0x28, 0x42, 0x00, 0x00, 0x1A, 0x0B)},
+		{name: "memarg memory index 1", feature: "multimemory", offset: 0x19,
+			bytes: withBody(0x00, 0x41, 0x00, 0x28, 0x42, 0x01, 0x00, 0x1A, 0x0B)},
+		{name: "memory.size 1", feature: "multimemory", offset: 0x17,
+			bytes: withBody(0x00, 0x3F, 0x01, 0x1A, 0x0B)},
+		{name: "memory.grow 1", feature: "multimemory", offset: 0x19,
+			bytes: withBody(0x00, 0x41, 0x00, 0x40, 0x01, 0x1A, 0x0B)},
+		{name: "data count section", feature: "bulk-memory", offset: 0x0A,
+			bytes: testModule([]byte{0x0C, 0x01, 0x00})},
+		{name: "mutable global import", feature: "mutable-globals", offset: 0x0B,
+			bytes: testModule([]byte{0x02, 0x08, 0x01, 0x01, 'm', 0x01, 'g', 0x03, 0x7F, 0x01})},
+		{name: "multi-value result", feature: "multivalue", offset: 0x0B,
+			bytes: testModule([]byte{0x01, 0x06, 0x01, 0x60, 0x00, 0x02, 0x7F, 0x7F})},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := DecodeModule(common.NewSliceBytes(c.bytes))
+			assert.Nil(t, err)
+			_, err = DecodeModuleWithOptions(common.NewSliceBytes(c.bytes), DecodeOptions{Features: common.FeaturesMVP})
+			assert.ErrorIs(t, err, ErrFeatureDisabled)
+			assert.ErrorContains(t, err, fmt.Sprintf("%s used at 0x%x", c.feature, c.offset))
+		})
+	}
+
+	// 2.0 has SIMD but not threads
+	opts := DecodeOptions{Features: common.Features20}
+	_, err := DecodeModuleWithOptions(common.NewSliceBytes(withBody(0x01, 0x01, 0x7B, 0x0B)), opts)
+	assert.Nil(t, err)
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(testModule(
+		[]byte{0x05, 0x04, 0x01, 0x03, 0x01, 0x02})), opts) // shared memory 1..2
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	assert.ErrorContains(t, err, "atomics used at 0xb")
+
+	// an MVP module decodes with only the core enabled
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(withBody(0x00, 0x41, 0x00, 0x1A, 0x0B)),
+		DecodeOptions{Features: common.FeaturesMVP})
+	assert.Nil(t, err)
+}
+
+func TestLazyCode(t *testing.T) {
+	buf, err := ioutil.ReadFile("../testdata/wasm/ch01_hw.wasm")
+	assert.Nil(t, err)
+	eager, err := DecodeModule(common.NewSliceBytes(buf))
+	assert.Nil(t, err)
+	lazy, err := DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{LazyCode: true})
+	assert.Nil(t, err)
+	if !assert.NotNil(t, lazy) || !assert.Len(t, lazy.CodeSec, len(eager.CodeSec)) {
+		return
+	}
+	for i, code := range lazy.CodeSec {
+		assert.Nil(t, code.Expr)
+		assert.Equal(t, eager.CodeSec[i].Offset, code.Offset)
+		assert.Equal(t, eager.CodeSec[i].Size, code.Size)
+	}
+
+	// the first callers decode a body, concurrently or not, only once
+	var wg sync.WaitGroup
+	codes := make([]*Code, 4)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i], _ = lazy.DecodeCode(0)
+		}(i)
+	}
+	wg.Wait()
+	for _, code := range codes {
+		assert.Same(t, lazy.CodeSec[0], code)
+	}
+	assert.Equal(t, eager.CodeSec[0].Locals, codes[0].Locals)
+	assert.Equal(t, eager.CodeSec[0].Expr, codes[0].Expr)
+	assert.Nil(t, lazy.CodeSec[1].Expr)
+
+	code, err := lazy.GetCode(common.FuncIdx(lazy.ImportedFuncCount() + 1))
+	assert.Nil(t, err)
+	assert.Equal(t, eager.CodeSec[1].Expr, code.Expr)
+	_, err = lazy.GetCode(common.FuncIdx(lazy.ImportedFuncCount() + len(lazy.CodeSec)))
+	assert.Error(t, err)
+	assert.Equal(t, eager.DisplayDetails(), lazy.DisplayDetails())
+
+	// errors in a body are only reported once it is decoded
+	bad := testModule(
+		[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00},             // type ()->()
+		[]byte{0x03, 0x02, 0x01, 0x00},                         // func 0
+		[]byte{0x0A, 0x06, 0x01, 0x04, 0x01, 0x01, 0x7B, 0x0B}) // a v128 local
+	opts := DecodeOptions{Features: common.FeaturesMVP}
+	_, err = DecodeModuleWithOptions(common.NewSliceBytes(bad), opts)
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	opts.LazyCode = true
+	module, err := DecodeModuleWithOptions(common.NewSliceBytes(bad), opts)
+	assert.Nil(t, err)
+	_, err = module.DecodeCode(0)
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	_, err = module.DecodeCode(0)
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+	_, err = module.DisplayDisassembly()
+	assert.ErrorIs(t, err, ErrFeatureDisabled)
+}
+
+// moduleWithBodies returns a module of n ()->() functions of the same body.
+func moduleWithBodies(n int, body []byte) []byte {
+	bodies := make([][]byte, n)
+	for i := range bodies {
+		bodies[i] = body
+	}
+	return moduleWithBodyList(bodies)
+}
+
+func moduleWithBodyList(bodies [][]byte) []byte {
+	n := len(bodies)
+	leb := func(v int) []byte {
+		return common.EncodeUint32(uint32(v))
+	}
+	funcs := append(leb(n), make([]byte, n)...)
+	code := leb(n)
+	for _, body := range bodies {
+		code = append(append(code, leb(len(body))...), body...)
+	}
+	return testModule(
+		[]byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00}, // type ()->()
+		append(append([]byte{0x03}, leb(len(funcs))...), funcs...),
+		append(append([]byte{0x0A}, leb(len(code))...), code...))
+}
+
+// testModule returns a module binary of the header followed by sections,
+// each its id, size and contents.
+func testModule(sections ...[]byte) []byte {
+	buf := []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+	for _, sec := range sections {
+		buf = append(buf, sec...)
+	}
+	return buf
+}
+
+// instrStrings returns the text format of each of instrs.
+func instrStrings(instrs []Instruction) []string {
+	strs := make([]string, len(instrs))
+	for i, instr := range instrs {
+		strs[i] = instr.String()
+	}
+	return strs
+}
+
+func TestParallelCode(t *testing.T) {
+	// padded with nops to make the code section big enough to be split
+	// between workers
+	pad := func(body ...byte) []byte {
+		return append(append(body, bytes.Repeat([]byte{0x01}, 1<<10)...), 0x0B)
+	}
+	body := pad(0x00, 0x41, 0x00, 0xC0, 0x1A) // i32.extend8_s
+	buf := moduleWithBodies(64, body)
+	for _, workers := range []int{1, 4} {
+		module, err := DecodeModuleWithOptions(common.NewSliceBytes(buf), DecodeOptions{CodeWorkers: workers})
+		assert.Nil(t, err)
+		if assert.Len(t, module.CodeSec, 64) {
+			for _, code := range module.CodeSec {
+				assert.Equal(t, body[1:], code.Expr.Data)
+			}
+		}
+
+		// with several bad bodies the first one is reported
+		_, err = DecodeModuleWithOptions(common.NewSliceBytes(buf),
+			DecodeOptions{CodeWorkers: workers, Features: common.FeaturesMVP})
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+		first := fmt.Sprintf("used at 0x%x", module.CodeSec[0].Offset+3)
+		assert.ErrorContains(t, err, first)
+
+		// a late bad body failing first must not hide a lower one
+		bodies := make([][]byte, 64)
+		for i := range bodies {
+			bodies[i] = pad(0x00, 0x41, 0x00, 0x1A, 0x01) // drop, nop
+		}
+		bodies[5], bodies[60] = body, body
+		_, err = DecodeModuleWithOptions(common.NewSliceBytes(moduleWithBodyList(bodies)),
+			DecodeOptions{CodeWorkers: workers, Features: common.FeaturesMVP})
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+		assert.ErrorContains(t, err, fmt.Sprintf("used at 0x%x", module.CodeSec[5].Offset+3))
+	}
+}
+
+func BenchmarkDecodeCode(b *testing.B) {
+	body := []byte{0x00}
+	for i := 0; i < 1000; i++ {
+		body = append(body, 0x41, 0x01, 0x41, 0x02, 0x6A, 0xC0, 0x1A) // (drop (i32.extend8_s (i32.add 1 2)))
+	}
+	buf := moduleWithBodies(1000, append(body, 0x0B))
+	// with a feature disabled (2.0 lacks tail calls) every body is decoded
+	// to check it; with all enabled, these bodies hold neither a tail call
+	// nor a wide memarg offset and are only split off. workers=0 is the
+	// default, GOMAXPROCS.
+	for _, c := range []struct {
+		name     string
+		features common.Features
+	}{{"2.0", common.Features20}, {"all", common.FeaturesAll}} {
+		for _, workers := range []int{0, 1, 4} {
+			b.Run(fmt.Sprintf("features=%s/workers=%d", c.name, workers), func(b *testing.B) {
+				opts := DecodeOptions{CodeWorkers: workers, Features: c.features}
+				b.SetBytes(int64(len(buf)))
+				for i := 0; i < b.N; i++ {
+					if _, err := DecodeModuleWithOptions(common.NewSliceBytes(buf), opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package decode
+
+import (
+	"fmt"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+const NameSectionName = "name"
+
+// Subsection ids of the name section, including those of the extended name
+// section proposal.
+const (
+	NameSubsecModule = iota
+	NameSubsecFunc
+	NameSubsecLocal
+	NameSubsecLabel
+	NameSubsecType
+	NameSubsecTable
+	NameSubsecMemory
+	NameSubsecGlobal
+	NameSubsecElem
+	NameSubsecData
+)
+
+type NameMap map[uint32]string
+type IndirectNameMap map[uint32]NameMap
+
+// NameSec is the decoded `name` custom section.
+type NameSec struct {
+	ModuleName  string
+	FuncNames   NameMap
+	LocalNames  IndirectNameMap // by function, then local index
+	LabelNames  IndirectNameMap // by function, then label index
+	TypeNames   NameMap
+	TableNames  NameMap
+	MemoryNames NameMap
+	GlobalNames NameMap
+	ElemNames   NameMap
+	DataNames   NameMap
+}
+
+// GetFuncName returns the name of a function in the function index space,
+// or "" if the module does not name it.
+func (module *Module) GetFuncName(idx common.FuncIdx) string {
+	if module.Names == nil {
+		return ""
+	}
+	return module.Names.FuncNames[idx]
+}
+
+// GetLocalName returns the name of a local of a function, or "".
+func (module *Module) GetLocalName(funcIdx common.FuncIdx, localIdx common.LocalIdx) string {
+	if module.Names == nil {
+		return ""
+	}
+	return module.Names.LocalNames[funcIdx][localIdx]
+}
+
+func decodeNameSection(data []byte) (*NameSec, error) {
+	bs := common.NewSliceBytes(data)
+	names := &NameSec{}
+	prevID := -1
+	for bs.Remaining() > 0 {
+		id, err := bs.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if int(id) <= prevID {
+			return nil, fmt.Errorf("name subsection %d out of order", id)
+		}
+		prevID = int(id)
+
+		size, err := decodeSize(bs, uint32(bs.Remaining()), "name subsection")
+		if err != nil {
+			return nil, err
+		}
+		sub, err := bs.ReadSlice(size)
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case NameSubsecModule:
+			names.ModuleName, _, err = sub.ReadName()
+		case NameSubsecFunc:
+			names.FuncNames, err = decodeNameMap(sub)
+		case NameSubsecLocal:
+			names.LocalNames, err = decodeIndirectNameMap(sub)
+		case NameSubsecLabel:
+			names.LabelNames, err = decodeIndirectNameMap(sub)
+		case NameSubsecType:
+			names.TypeNames, err = decodeNameMap(sub)
+		case NameSubsecTable:
+			names.TableNames, err = decodeNameMap(sub)
+		case NameSubsecMemory:
+			names.MemoryNames, err = decodeNameMap(sub)
+		case NameSubsecGlobal:
+			names.GlobalNames, err = decodeNameMap(sub)
+		case NameSubsecElem:
+			names.ElemNames, err = decodeNameMap(sub)
+		case NameSubsecData:
+			names.DataNames, err = decodeNameMap(sub)
+		default:
+			// unknown subsections are skipped
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("name subsection %d: %w", id, err)
+		}
+		if sub.Remaining() != 0 {
+			return nil, fmt.Errorf("%w: name subsection %d has %d trailing bytes", ErrSectionSizeMismatch, id, sub.Remaining())
+		}
+	}
+	return names, nil
+}
+
+func decodeNameMap(bs *common.SliceBytes) (NameMap, error) {
+	count, err := decodeVecCount(bs, "name")
+	if err != nil {
+		return nil, err
+	}
+	nameMap := make(NameMap, count)
+	for i := uint32(0); i < count; i++ {
+		idx, _, err := common.DecodeUint32(bs)
+		if err != nil {
+			return nil, err
+		}
+		name, _, err := bs.ReadName()
+		if err != nil {
+			return nil, err
+		}
+		nameMap[idx] = name
+	}
+	return nameMap, nil
+}
+
+func decodeIndirectNameMap(bs *common.SliceBytes) (IndirectNameMap, error) {
+	count, err := decodeVecCount(bs, "name map")
+	if err != nil {
+		return nil, err
+	}
+	indirect := make(IndirectNameMap, count)
+	for i := uint32(0); i < count; i++ {
+		idx, _, err := common.DecodeUint32(bs)
+		if err != nil {
+			return nil, err
+		}
+		nameMap, err := decodeNameMap(bs)
+		if err != nil {
+			return nil, err
+		}
+		indirect[idx] = nameMap
+	}
+	return indirect, nil
+}
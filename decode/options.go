@@ -0,0 +1,142 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+var (
+	ErrLimitExceeded       = errors.New("decode limit exceeded")
+	ErrSectionSizeMismatch = errors.New("section size mismatch")
+)
+
+// Default limits, in line with what browser engines accept.
+const (
+	DefaultMaxTypes             = 1000000
+	DefaultMaxFunctions         = 1000000
+	DefaultMaxLocals            = 50000
+	DefaultMaxBodySize          = 7654321
+	DefaultMaxDataSize          = 1 << 30
+	DefaultMaxCustomSectionSize = 1 << 30
+)
+
+// DecodeOptions bounds how much a module may ask the decoder to allocate,
+// and selects the proposals it accepts. A zero field means the corresponding
+// default is used.
+type DecodeOptions struct {
+	MaxTypes             uint32 // entries in the type section
+	MaxFunctions         uint32 // entries in the function and code sections
+	MaxLocals            uint32 // locals declared by a single function
+	MaxBodySize          uint32 // bytes in a single function body
+	MaxDataSize          uint32 // bytes in a single data segment
+	MaxCustomSectionSize uint32 // bytes in a single custom section
+
+	// Features are the proposals a module may use, common.FeaturesAll by
+	// default. Using any other fails with ErrFeatureDisabled.
+	Features common.Features
+	// CheckTargetFeatures also fails a module whose target_features section
+	// says it was built to use a feature not in Features, even if the
+	// decoder does not meet that feature in the module itself.
+	CheckTargetFeatures bool
+
+	// LazyCode leaves function bodies undecoded: Code records only where
+	// each body is, and its locals and instructions are decoded and checked
+	// by Module.DecodeCode, so a malformed body is only reported then.
+	LazyCode bool
+	// CodeWorkers is how many goroutines decode function bodies when they
+	// are not lazy, runtime.GOMAXPROCS(0) by default. A code section of
+	// fewer than 64 bodies or 64 KiB is decoded on one goroutine whatever
+	// the setting, as starting workers would cost more than they save.
+	CodeWorkers int
+
+	// CustomSections decodes custom sections by name; nil means the
+	// registry returned by NewCustomSectionRegistry
+	CustomSections *CustomSectionRegistry
+}
+
+func DefaultDecodeOptions() DecodeOptions {
+	return DecodeOptions{
+		MaxTypes:             DefaultMaxTypes,
+		MaxFunctions:         DefaultMaxFunctions,
+		MaxLocals:            DefaultMaxLocals,
+		MaxBodySize:          DefaultMaxBodySize,
+		MaxDataSize:          DefaultMaxDataSize,
+		MaxCustomSectionSize: DefaultMaxCustomSectionSize,
+		Features:             common.FeaturesAll,
+		CodeWorkers:          runtime.GOMAXPROCS(0),
+	}
+}
+
+func (opts DecodeOptions) withDefaults() DecodeOptions {
+	def := DefaultDecodeOptions()
+	if opts.MaxTypes == 0 {
+		opts.MaxTypes = def.MaxTypes
+	}
+	if opts.MaxFunctions == 0 {
+		opts.MaxFunctions = def.MaxFunctions
+	}
+	if opts.MaxLocals == 0 {
+		opts.MaxLocals = def.MaxLocals
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = def.MaxBodySize
+	}
+	if opts.MaxDataSize == 0 {
+		opts.MaxDataSize = def.MaxDataSize
+	}
+	if opts.MaxCustomSectionSize == 0 {
+		opts.MaxCustomSectionSize = def.MaxCustomSectionSize
+	}
+	if opts.Features == 0 {
+		opts.Features = def.Features
+	}
+	if opts.CodeWorkers <= 0 {
+		opts.CodeWorkers = def.CodeWorkers
+	}
+	if opts.CustomSections == nil {
+		opts.CustomSections = builtinCustomSections
+	}
+	return opts
+}
+
+// decodeCount reads the length of a vector. Every element takes at least one
+// byte, so a count larger than what is left of the section is rejected before
+// anything gets allocated for it.
+func decodeCount(bs *common.SliceBytes, max uint32, what string) (uint32, error) {
+	count, _, err := common.DecodeUint32(bs)
+	if err != nil {
+		return 0, err
+	}
+	if count > max {
+		return 0, fmt.Errorf("%w: %s count %d > %d", ErrLimitExceeded, what, count, max)
+	}
+	if int64(count) > int64(bs.Remaining()) {
+		return 0, fmt.Errorf("%s count %d exceeds remaining %d bytes: %w", what, count, bs.Remaining(), io.ErrUnexpectedEOF)
+	}
+	return count, nil
+}
+
+// decodeVecCount is decodeCount for vectors without a configurable limit.
+func decodeVecCount(bs *common.SliceBytes, what string) (uint32, error) {
+	return decodeCount(bs, math.MaxUint32, what)
+}
+
+// decodeSize reads a byte length and checks it against max and the bytes left.
+func decodeSize(bs *common.SliceBytes, max uint32, what string) (int, error) {
+	size, _, err := common.DecodeUint32(bs)
+	if err != nil {
+		return 0, err
+	}
+	if size > max {
+		return 0, fmt.Errorf("%w: %s size %d > %d", ErrLimitExceeded, what, size, max)
+	}
+	if int64(size) > int64(bs.Remaining()) {
+		return 0, fmt.Errorf("%s size %d exceeds remaining %d bytes: %w", what, size, bs.Remaining(), io.ErrUnexpectedEOF)
+	}
+	return int(size), nil
+}
@@ -0,0 +1,150 @@
+package decode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+const (
+	ProducersSectionName      = "producers"
+	TargetFeaturesSectionName = "target_features"
+)
+
+// Field names of the producers section.
+const (
+	ProducersFieldLanguage    = "language"
+	ProducersFieldProcessedBy = "processed-by"
+	ProducersFieldSDK         = "sdk"
+)
+
+// Prefixes of a target_features entry.
+const (
+	TargetFeatureUsed       byte = '+'
+	TargetFeatureDisallowed byte = '-'
+	TargetFeatureRequired   byte = '='
+)
+
+type ProducerValue struct {
+	Name    string
+	Version string
+}
+
+// ProducersSec is the decoded `producers` custom section: which languages
+// a module was written in and which tools and SDKs produced it.
+type ProducersSec struct {
+	Language    []ProducerValue
+	ProcessedBy []ProducerValue
+	SDK         []ProducerValue
+}
+
+type TargetFeature struct {
+	Prefix byte
+	Name   string
+}
+
+// TargetFeaturesSec is the decoded `target_features` custom section.
+type TargetFeaturesSec struct {
+	Features []TargetFeature
+}
+
+func decodeProducersSection(data []byte) (*ProducersSec, error) {
+	bs := common.NewSliceBytes(data)
+	producers := &ProducersSec{}
+	fieldCount, err := decodeVecCount(bs, "producers field")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for i := uint32(0); i < fieldCount; i++ {
+		field, _, err := bs.ReadName()
+		if err != nil {
+			return nil, err
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate producers field %q", field)
+		}
+		seen[field] = true
+
+		valueCount, err := decodeVecCount(bs, "producers value")
+		if err != nil {
+			return nil, err
+		}
+		values := make([]ProducerValue, 0, valueCount)
+		for j := uint32(0); j < valueCount; j++ {
+			var value ProducerValue
+			if value.Name, _, err = bs.ReadName(); err != nil {
+				return nil, err
+			}
+			if value.Version, _, err = bs.ReadName(); err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		switch field {
+		case ProducersFieldLanguage:
+			producers.Language = values
+		case ProducersFieldProcessedBy:
+			producers.ProcessedBy = values
+		case ProducersFieldSDK:
+			producers.SDK = values
+		}
+	}
+	if bs.Remaining() != 0 {
+		return nil, fmt.Errorf("%w: producers section has %d trailing bytes", ErrSectionSizeMismatch, bs.Remaining())
+	}
+	return producers, nil
+}
+
+func decodeTargetFeaturesSection(data []byte) (*TargetFeaturesSec, error) {
+	bs := common.NewSliceBytes(data)
+	count, err := decodeVecCount(bs, "target feature")
+	if err != nil {
+		return nil, err
+	}
+
+	features := &TargetFeaturesSec{Features: make([]TargetFeature, 0, count)}
+	for i := uint32(0); i < count; i++ {
+		prefix, err := bs.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if prefix != TargetFeatureUsed && prefix != TargetFeatureDisallowed && prefix != TargetFeatureRequired {
+			return nil, fmt.Errorf("invalid target feature prefix 0x%02x", prefix)
+		}
+		name, _, err := bs.ReadName()
+		if err != nil {
+			return nil, err
+		}
+		features.Features = append(features.Features, TargetFeature{Prefix: prefix, Name: name})
+	}
+	if bs.Remaining() != 0 {
+		return nil, fmt.Errorf("%w: target_features section has %d trailing bytes", ErrSectionSizeMismatch, bs.Remaining())
+	}
+	return features, nil
+}
+
+// Check returns an error wrapping ErrFeatureDisabled and naming every
+// feature the module was built to use that is not in enabled. Names
+// common.FeatureByName does not know are ignored: toolchains add names
+// faster than decoders learn them, and the decoder still rejects whatever
+// it meets in the module and cannot decode.
+func (sec *TargetFeaturesSec) Check(enabled common.Features) error {
+	var missing []string
+	for _, feature := range sec.Features {
+		if feature.Prefix == TargetFeatureDisallowed {
+			continue
+		}
+		if f, ok := common.FeatureByName(feature.Name); ok && !enabled.Has(f) {
+			missing = append(missing, feature.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: module requires features that are not enabled: %s",
+			ErrFeatureDisabled, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package decode
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/opcode"
+)
+
+// checkTailCall checks that the callee of a return_call,
+// return_call_indirect or return_call_ref returns what caller, the type of
+// the function making the call, does. Other instructions are not checked.
+func (module *Module) checkTailCall(caller *common.FuncType, instr *Instruction) error {
+	var callee *common.FuncType
+	var err error
+	switch instr.Opcode {
+	case opcode.ReturnCall:
+		callee, err = module.GetFuncType(common.FuncIdx(instr.Index))
+	case opcode.ReturnCallIndirect, opcode.ReturnCallRef:
+		callee, err = module.GetType(common.TypeIdx(instr.Index))
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s at 0x%x: %v", ErrInvalid, instr.Name(), instr.Offset, err)
+	}
+	if !module.matchValTypes(callee.ReturnTypes, caller.ReturnTypes) {
+		return fmt.Errorf("%w: %s at 0x%x: callee results %s do not match function results %s", ErrInvalid,
+			instr.Name(), instr.Offset, displayValTypes(callee.ReturnTypes), displayValTypes(caller.ReturnTypes))
+	}
+	return nil
+}
+
+// checkMemArg checks that the offset of a memory instruction fits its
+// memory: only a memory64 memory takes offsets of 2^32 and above.
+func (module *Module) checkMemArg(instr *Instruction) error {
+	if instr.MemArg.Offset <= math.MaxUint32 {
+		return nil
+	}
+	mt, err := module.GetMemType(instr.MemArg.Mem)
+	if err != nil {
+		return fmt.Errorf("%w: %s at 0x%x: %v", ErrInvalid, instr.Name(), instr.Offset, err)
+	}
+	if !mt.LimitsRef.Is64() {
+		return fmt.Errorf("%w: %s at 0x%x: offset %d is out of range for a 32-bit memory", ErrInvalid,
+			instr.Name(), instr.Offset, instr.MemArg.Offset)
+	}
+	return nil
+}
+
+// matchValTypes reports whether every type of subs matches the type of
+// supers at the same position.
+func (module *Module) matchValTypes(subs, supers []common.ValType) bool {
+	if len(subs) != len(supers) {
+		return false
+	}
+	for i := range subs {
+		if !module.matchValType(subs[i], supers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchValType reports whether sub is a subtype of super. Defined types are
+// compared by index: equivalent types of different recursion groups are not
+// recognized yet.
+func (module *Module) matchValType(sub, super common.ValType) bool {
+	if sub == super {
+		return true
+	}
+	if !sub.IsRef() || !super.IsRef() || sub.Nullable() && !super.Nullable() {
+		return false
+	}
+	return module.matchHeapType(sub.HeapType(), super.HeapType())
+}
+
+func (module *Module) matchHeapType(sub, super common.HeapType) bool {
+	if sub == super {
+		return true
+	}
+	if sub.IsIndex() {
+		if !super.IsIndex() {
+			return module.matchHeapType(module.abstractHeapType(sub), super)
+		}
+		for idx := common.TypeIdx(sub); ; idx = module.TypeSec[idx].SuperTypes[0] {
+			if idx == common.TypeIdx(super) {
+				return true
+			}
+			if int64(idx) >= int64(len(module.TypeSec)) || len(module.TypeSec[idx].SuperTypes) == 0 {
+				return false
+			}
+		}
+	}
+	if super.IsIndex() {
+		// only the bottom type of its hierarchy is below a defined type
+		if module.abstractHeapType(super) == common.HeapTypeFunc {
+			return sub == common.HeapTypeNoFunc
+		}
+		return sub == common.HeapTypeNone
+	}
+
+	switch sub {
+	case common.HeapTypeI31, common.HeapTypeStruct, common.HeapTypeArray:
+		return super == common.HeapTypeEq || super == common.HeapTypeAny
+	case common.HeapTypeEq:
+		return super == common.HeapTypeAny
+	case common.HeapTypeNone:
+		return super == common.HeapTypeAny || super == common.HeapTypeEq || super == common.HeapTypeI31 ||
+			super == common.HeapTypeStruct || super == common.HeapTypeArray
+	case common.HeapTypeNoFunc:
+		return super == common.HeapTypeFunc
+	case common.HeapTypeNoExtern:
+		return super == common.HeapTypeExtern
+	case common.HeapTypeNoExn:
+		return super == common.HeapTypeExn
+	}
+	return false
+}
+
+// abstractHeapType returns the abstract heap type a defined type is
+// directly below: func, struct or array.
+func (module *Module) abstractHeapType(ht common.HeapType) common.HeapType {
+	if int64(ht) < int64(len(module.TypeSec)) {
+		switch module.TypeSec[ht].Comp.Tag {
+		case common.TagStructType:
+			return common.HeapTypeStruct
+		case common.TagArrayType:
+			return common.HeapTypeArray
+		}
+	}
+	return common.HeapTypeFunc
+}
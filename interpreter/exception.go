@@ -0,0 +1,66 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/decode"
+)
+
+// Tag is an exception tag instance. Tags are compared by identity: two tags
+// with the same type are still different tags, so a host that wants to throw
+// an exception a module can catch must use the tag the module imports or
+// exports.
+type Tag struct {
+	Type *common.FuncType // its parameters are the values the exception carries
+}
+
+// Exception is a thrown exception. An exnref refers to it, so on the operand
+// stack it is a reference like a GC object, and it is returned as an error
+// when an exception escapes to the host.
+type Exception struct {
+	Tag    *Tag
+	Values []Value // one per parameter of the tag's type
+}
+
+// NewException creates an exception of tag carrying values, e.g. for a host
+// function to throw into guest code.
+func NewException(tag *Tag, values ...Value) (*Exception, error) {
+	if len(values) != len(tag.Type.InputTypes) {
+		return nil, fmt.Errorf("tag takes %d values, got %d", len(tag.Type.InputTypes), len(values))
+	}
+	return &Exception{Tag: tag, Values: values}, nil
+}
+
+func (exn *Exception) Error() string {
+	return fmt.Sprintf("uncaught exception with %d values", len(exn.Values))
+}
+
+// findCatch returns the first catch clause of a try_table that handles exn.
+// tags is the tag index space of the module the try_table belongs to.
+func findCatch(catches []decode.Catch, tags []*Tag, exn *Exception) (decode.Catch, bool) {
+	for _, c := range catches {
+		switch c.Kind {
+		case decode.CatchKindCatchAll, decode.CatchKindCatchAllRef:
+			return c, true
+		}
+		if int64(c.Tag) < int64(len(tags)) && tags[c.Tag] == exn.Tag {
+			return c, true
+		}
+	}
+	return decode.Catch{}, false
+}
+
+// pushCaught pushes what a catch clause passes to its label: the
+// exception's values unless it is a catch_all, then exn itself as the
+// exnref for the _ref kinds.
+func pushCaught(s *OperandStack, c decode.Catch, exn *Exception) {
+	if c.Kind == decode.CatchKindCatch || c.Kind == decode.CatchKindCatchRef {
+		for _, val := range exn.Values {
+			s.push(val)
+		}
+	}
+	if c.Kind == decode.CatchKindCatchRef || c.Kind == decode.CatchKindCatchAllRef {
+		s.pushRef(exn)
+	}
+}
@@ -0,0 +1,46 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/decode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestException(t *testing.T) {
+	carried := &common.FuncType{InputTypes: []common.ValType{common.ValTypeI32, common.ValTypeExternRef}}
+	a, b := &Tag{Type: carried}, &Tag{Type: carried}
+
+	_, err := NewException(a)
+	assert.Error(t, err)
+	host := ToExternRef("host")
+	exn, err := NewException(a, Value{Num: common.V128{Lo: 42}}, Value{Ref: host})
+	require.NoError(t, err)
+
+	catches := []decode.Catch{
+		{Kind: decode.CatchKindCatch, Tag: 1, Label: 0},
+		{Kind: decode.CatchKindCatchRef, Tag: 0, Label: 1},
+		{Kind: decode.CatchKindCatchAll, Label: 2},
+	}
+	c, ok := findCatch(catches, []*Tag{a, b}, exn)
+	require.True(t, ok)
+	assert.Equal(t, catches[1], c)
+
+	s := &OperandStack{}
+	pushCaught(s, c, exn)
+	assert.Same(t, exn, s.popRef())
+	assert.Same(t, host, s.popRef())
+	assert.Equal(t, uint32(42), s.popU32())
+	assert.Empty(t, s.slots)
+	pushCaught(s, decode.Catch{Kind: decode.CatchKindCatchAll}, exn)
+	assert.Empty(t, s.slots)
+
+	// tags are matched by identity, not by type
+	c, ok = findCatch(catches[:2], []*Tag{b, b}, exn)
+	assert.False(t, ok)
+	c, ok = findCatch(catches, []*Tag{b, b}, exn)
+	require.True(t, ok)
+	assert.Equal(t, catches[2], c)
+}
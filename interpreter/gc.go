@@ -0,0 +1,393 @@
+package interpreter
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+var (
+	ErrCastFailure      = errors.New("cast failure")
+	ErrArrayTooLarge    = errors.New("array too large")
+	ErrArrayOutOfBounds = errors.New("out of bounds array access")
+	errTypeMismatch     = errors.New("type mismatch")
+)
+
+// MaxArrayBytes bounds the storage of a single array, so that a guest
+// asking for a huge one traps instead of exhausting the host's memory.
+const MaxArrayBytes = 1 << 30
+
+// Struct is an instance of a struct type. GC objects are ordinary Go
+// values, so Go's garbage collector reclaims them once the instance no
+// longer reaches them. Fields are laid out as array elements are: number
+// and vector fields little endian at the size of their storage type,
+// reference fields kept apart.
+type Struct struct {
+	Type   common.TypeIdx
+	fields []common.FieldType
+	offset []int  // of each field, in data or, for a reference, in refs
+	data   []byte // number and vector fields
+	refs   []any  // reference fields
+}
+
+// Array is an instance of an array type. Number and vector elements are
+// stored little endian at the size of their storage type, so an i8 array
+// takes a byte per element; reference elements are kept apart.
+type Array struct {
+	Type     common.TypeIdx
+	elemType common.ValType
+	data     []byte // number and vector elements
+	refs     []any  // reference elements
+}
+
+// I31 is the value of an i31ref, an unboxed 31-bit integer.
+type I31 uint32
+
+// NewI31 implements ref.i31: it keeps the low 31 bits of v.
+func NewI31(v uint32) I31 {
+	return I31(v & 0x7FFFFFFF)
+}
+
+// GetS implements i31.get_s.
+func (i I31) GetS() int32 {
+	return int32(i<<1) >> 1
+}
+
+// GetU implements i31.get_u.
+func (i I31) GetU() uint32 {
+	return uint32(i)
+}
+
+// NewStruct implements struct.new and, with nil fields, struct.new_default.
+// Each of fields holds the value of a field in Num or, for a reference
+// field, in Ref.
+func NewStruct(types []*common.SubType, idx common.TypeIdx, fields []Value) (*Struct, error) {
+	comp, err := compType(types, idx, common.TagStructType)
+	if err != nil {
+		return nil, err
+	}
+	if fields != nil && len(fields) != len(comp.Fields) {
+		return nil, errors.New("wrong number of struct fields")
+	}
+	s := &Struct{Type: idx, fields: comp.Fields, offset: make([]int, len(comp.Fields))}
+	size := 0
+	for i, field := range comp.Fields {
+		if n := storageSize(field.Type); n > 0 {
+			s.offset[i] = size
+			size += n
+		} else {
+			s.offset[i] = len(s.refs)
+			s.refs = append(s.refs, nil)
+		}
+	}
+	s.data = make([]byte, size)
+	for i, field := range fields {
+		if s.isRef(uint32(i)) {
+			s.refs[s.offset[i]] = field.Ref
+		} else {
+			_ = s.Set(uint32(i), field.Num)
+		}
+	}
+	return s, nil
+}
+
+func (s *Struct) isRef(i uint32) bool {
+	return storageSize(s.fields[i].Type) == 0
+}
+
+// field returns the bytes of field i, a number or vector field.
+func (s *Struct) field(i uint32) ([]byte, error) {
+	if i >= uint32(len(s.fields)) || s.isRef(i) {
+		return nil, errTypeMismatch
+	}
+	return s.data[s.offset[i] : s.offset[i]+storageSize(s.fields[i].Type)], nil
+}
+
+// Get implements struct.get and struct.get_u of a number or vector field:
+// packed fields are zero-extended.
+func (s *Struct) Get(i uint32) (common.V128, error) {
+	return s.get(i, false)
+}
+
+// GetS implements struct.get_s, which sign-extends a packed field.
+func (s *Struct) GetS(i uint32) (common.V128, error) {
+	return s.get(i, true)
+}
+
+func (s *Struct) get(i uint32, signed bool) (common.V128, error) {
+	b, err := s.field(i)
+	if err != nil {
+		return common.V128{}, err
+	}
+	return unpackField(s.fields[i].Type, loadStorage(b), signed), nil
+}
+
+// Set implements struct.set of a number or vector field, wrapping packed
+// ones.
+func (s *Struct) Set(i uint32, v common.V128) error {
+	b, err := s.field(i)
+	if err != nil {
+		return err
+	}
+	storeStorage(b, v)
+	return nil
+}
+
+// GetRef implements struct.get of a reference field.
+func (s *Struct) GetRef(i uint32) (any, error) {
+	if i >= uint32(len(s.fields)) || !s.isRef(i) {
+		return nil, errTypeMismatch
+	}
+	return s.refs[s.offset[i]], nil
+}
+
+// SetRef implements struct.set of a reference field.
+func (s *Struct) SetRef(i uint32, ref any) error {
+	if i >= uint32(len(s.fields)) || !s.isRef(i) {
+		return errTypeMismatch
+	}
+	s.refs[s.offset[i]] = ref
+	return nil
+}
+
+// NewArray implements array.new for arrays of numbers or vectors: an array
+// of n elements set to val. It fails with ErrArrayTooLarge rather than
+// allocating more than MaxArrayBytes.
+func NewArray(types []*common.SubType, idx common.TypeIdx, n uint32, val common.V128) (*Array, error) {
+	a, err := newArray(types, idx, n)
+	if err != nil {
+		return nil, err
+	}
+	if a.refs != nil {
+		return nil, errTypeMismatch
+	}
+	for i := uint32(0); i < n; i++ {
+		_ = a.Set(i, val)
+	}
+	return a, nil
+}
+
+// NewRefArray implements array.new for arrays of references: an array of n
+// elements set to ref.
+func NewRefArray(types []*common.SubType, idx common.TypeIdx, n uint32, ref any) (*Array, error) {
+	a, err := newArray(types, idx, n)
+	if err != nil {
+		return nil, err
+	}
+	if a.refs == nil {
+		return nil, errTypeMismatch
+	}
+	for i := range a.refs {
+		a.refs[i] = ref
+	}
+	return a, nil
+}
+
+// refSize is what a reference element costs, the size of an interface
+// value.
+const refSize = 16
+
+func newArray(types []*common.SubType, idx common.TypeIdx, n uint32) (*Array, error) {
+	comp, err := compType(types, idx, common.TagArrayType)
+	if err != nil {
+		return nil, err
+	}
+	a := &Array{Type: idx, elemType: comp.Fields[0].Type}
+	size := storageSize(a.elemType)
+	if size == 0 {
+		if uint64(n)*refSize > MaxArrayBytes {
+			return nil, ErrArrayTooLarge
+		}
+		a.refs = make([]any, n)
+		return a, nil
+	}
+	if uint64(n)*uint64(size) > MaxArrayBytes {
+		return nil, ErrArrayTooLarge
+	}
+	a.data = make([]byte, int(n)*size)
+	return a, nil
+}
+
+// storageSize returns the size in bytes of a number, vector or packed
+// storage type, or 0 for a reference type.
+func storageSize(st common.ValType) int {
+	switch st {
+	case common.StorageTypeI8:
+		return 1
+	case common.StorageTypeI16:
+		return 2
+	case common.ValTypeI32, common.ValTypeF32:
+		return 4
+	case common.ValTypeI64, common.ValTypeF64:
+		return 8
+	case common.ValTypeV128:
+		return 16
+	}
+	return 0
+}
+
+// Len implements array.len.
+func (a *Array) Len() uint32 {
+	if a.refs != nil {
+		return uint32(len(a.refs))
+	}
+	return uint32(len(a.data) / storageSize(a.elemType))
+}
+
+// elem returns the bytes of element i of a number or vector array.
+func (a *Array) elem(i uint32) ([]byte, error) {
+	if a.refs != nil {
+		return nil, errTypeMismatch
+	}
+	if i >= a.Len() {
+		return nil, ErrArrayOutOfBounds
+	}
+	size := storageSize(a.elemType)
+	return a.data[int(i)*size : int(i+1)*size], nil
+}
+
+// Get implements array.get and array.get_u of a number or vector element:
+// packed elements are zero-extended.
+func (a *Array) Get(i uint32) (common.V128, error) {
+	return a.get(i, false)
+}
+
+// GetS implements array.get_s, which sign-extends a packed element.
+func (a *Array) GetS(i uint32) (common.V128, error) {
+	return a.get(i, true)
+}
+
+func (a *Array) get(i uint32, signed bool) (common.V128, error) {
+	b, err := a.elem(i)
+	if err != nil {
+		return common.V128{}, err
+	}
+	return unpackField(a.elemType, loadStorage(b), signed), nil
+}
+
+// Set implements array.set of a number or vector element, wrapping packed
+// ones.
+func (a *Array) Set(i uint32, v common.V128) error {
+	b, err := a.elem(i)
+	if err != nil {
+		return err
+	}
+	storeStorage(b, v)
+	return nil
+}
+
+// GetRef implements array.get of a reference element.
+func (a *Array) GetRef(i uint32) (any, error) {
+	if a.refs == nil {
+		return nil, errTypeMismatch
+	}
+	if i >= a.Len() {
+		return nil, ErrArrayOutOfBounds
+	}
+	return a.refs[i], nil
+}
+
+// SetRef implements array.set of a reference element.
+func (a *Array) SetRef(i uint32, ref any) error {
+	if a.refs == nil {
+		return errTypeMismatch
+	}
+	if i >= a.Len() {
+		return ErrArrayOutOfBounds
+	}
+	a.refs[i] = ref
+	return nil
+}
+
+func compType(types []*common.SubType, idx common.TypeIdx, tag byte) (*common.CompType, error) {
+	if int64(idx) >= int64(len(types)) || types[idx].Comp.Tag != tag {
+		return nil, errTypeMismatch
+	}
+	return &types[idx].Comp, nil
+}
+
+// loadStorage reads a field or element stored little endian in b, zero
+// extending it.
+func loadStorage(b []byte) common.V128 {
+	var buf [16]byte
+	copy(buf[:], b)
+	return common.V128{Lo: binary.LittleEndian.Uint64(buf[:8]), Hi: binary.LittleEndian.Uint64(buf[8:])}
+}
+
+// storeStorage writes v little endian into b, keeping as many low bytes as
+// b holds: this is what wraps a value stored into a packed field.
+func storeStorage(b []byte, v common.V128) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], v.Lo)
+	binary.LittleEndian.PutUint64(buf[8:], v.Hi)
+	copy(b, buf[:])
+}
+
+// unpackField extends a value read from a field of storage type st to an
+// i32, as struct.get_s/_u and array.get_s/_u do for packed fields.
+func unpackField(st common.ValType, v common.V128, signed bool) common.V128 {
+	switch {
+	case st == common.StorageTypeI8 && signed:
+		return common.V128{Lo: uint64(uint32(int32(int8(v.Lo))))}
+	case st == common.StorageTypeI16 && signed:
+		return common.V128{Lo: uint64(uint32(int32(int16(v.Lo))))}
+	}
+	return v
+}
+
+// isSubType reports whether the defined type sub matches super, following
+// the declared supertypes. Types are compared by index: equivalent types of
+// different recursion groups are not recognized yet.
+func isSubType(types []*common.SubType, sub, super common.TypeIdx) bool {
+	for {
+		if sub == super {
+			return true
+		}
+		if int64(sub) >= int64(len(types)) || len(types[sub].SuperTypes) == 0 {
+			return false
+		}
+		sub = types[sub].SuperTypes[0]
+	}
+}
+
+// RefTest implements ref.test: it reports whether ref, a GC object, a
+// *FuncRef, an *ExternRef or nil for null, matches the reference type
+// target.
+func RefTest(types []*common.SubType, ref any, target common.ValType) bool {
+	if ref == nil {
+		return target.Nullable()
+	}
+	ht := target.HeapType()
+	switch obj := ref.(type) {
+	case I31:
+		return ht == common.HeapTypeAny || ht == common.HeapTypeEq || ht == common.HeapTypeI31
+	case *Struct:
+		if ht.IsIndex() {
+			return isSubType(types, obj.Type, common.TypeIdx(ht))
+		}
+		return ht == common.HeapTypeAny || ht == common.HeapTypeEq || ht == common.HeapTypeStruct
+	case *Array:
+		if ht.IsIndex() {
+			return isSubType(types, obj.Type, common.TypeIdx(ht))
+		}
+		return ht == common.HeapTypeAny || ht == common.HeapTypeEq || ht == common.HeapTypeArray
+	case *FuncRef:
+		// functions are only ever below func, never in the any hierarchy
+		if ht.IsIndex() {
+			return isSubType(types, obj.Type, common.TypeIdx(ht))
+		}
+		return ht == common.HeapTypeFunc
+	case *ExternRef:
+		return ht == common.HeapTypeExtern
+	}
+	return false
+}
+
+// RefCast implements ref.cast, which traps where ref.test fails.
+func RefCast(types []*common.SubType, ref any, target common.ValType) error {
+	if !RefTest(types, ref, target) {
+		return ErrCastFailure
+	}
+	return nil
+}
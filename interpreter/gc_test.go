@@ -0,0 +1,142 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGC(t *testing.T) {
+	point := common.CompType{Tag: common.TagStructType, Fields: []common.FieldType{
+		{Type: common.ValTypeI32, Mutable: true},
+		{Type: common.StorageTypeI8},
+	}}
+	types := []*common.SubType{
+		{Comp: point}, // 0, open
+		{Final: true, SuperTypes: []common.TypeIdx{0}, Comp: point}, // 1 <: 0
+		{Final: true, Comp: common.CompType{Tag: common.TagArrayType, Fields: []common.FieldType{
+			{Type: common.StorageTypeI16, Mutable: true},
+		}}}, // 2
+		{Final: true, Comp: common.CompType{Tag: common.TagArrayType, Fields: []common.FieldType{
+			{Type: common.ValTypeAnyRef, Mutable: true},
+		}}}, // 3
+		{Final: true, Comp: common.CompType{Tag: common.TagArrayType, Fields: []common.FieldType{
+			{Type: common.ValTypeV128},
+		}}}, // 4
+		{Comp: common.CompType{Tag: common.TagFuncType}},                                               // 5, open
+		{Final: true, SuperTypes: []common.TypeIdx{5}, Comp: common.CompType{Tag: common.TagFuncType}}, // 6 <: 5
+		{Final: true, Comp: common.CompType{Tag: common.TagStructType, Fields: []common.FieldType{
+			{Type: common.ValTypeAnyRef, Mutable: true},
+			{Type: common.ValTypeI64, Mutable: true},
+		}}}, // 7
+	}
+
+	s, err := NewStruct(types, 1, []Value{{Num: common.V128{Lo: 7}}, {Num: common.V128{Lo: 0x1FF}}})
+	require.NoError(t, err)
+	assert.Len(t, s.data, 5, "an i32 and an i8 field take five bytes")
+	v, err := s.Get(0)
+	require.NoError(t, err)
+	assert.Equal(t, common.V128{Lo: 7}, v)
+	v, _ = s.Get(1)
+	assert.Equal(t, common.V128{Lo: 0xFF}, v)
+	v, _ = s.GetS(1)
+	assert.Equal(t, common.V128{Lo: 0xFFFFFFFF}, v)
+	_, err = s.Get(2)
+	assert.Error(t, err)
+	_, err = NewStruct(types, 2, nil)
+	assert.Error(t, err)
+	_, err = NewStruct(types, 1, []Value{{}})
+	assert.Error(t, err)
+
+	mixed, err := NewStruct(types, 7, nil)
+	require.NoError(t, err)
+	ref, err := mixed.GetRef(0)
+	require.NoError(t, err)
+	assert.Nil(t, ref)
+	require.NoError(t, mixed.SetRef(0, s))
+	require.NoError(t, mixed.Set(1, common.V128{Lo: 1 << 40}))
+	ref, _ = mixed.GetRef(0)
+	assert.Same(t, s, ref)
+	v, _ = mixed.Get(1)
+	assert.Equal(t, common.V128{Lo: 1 << 40}, v)
+	_, err = mixed.Get(0)
+	assert.Error(t, err)
+	assert.Error(t, mixed.SetRef(1, nil))
+
+	a, err := NewArray(types, 2, 3, common.V128{Lo: 0x18000})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), a.Len())
+	assert.Len(t, a.data, 6, "i16 elements take two bytes")
+	v, err = a.Get(2)
+	require.NoError(t, err)
+	assert.Equal(t, common.V128{Lo: 0x8000}, v)
+	v, _ = a.GetS(2)
+	assert.Equal(t, common.V128{Lo: 0xFFFF8000}, v)
+	require.NoError(t, a.Set(1, common.V128{Lo: 0x12345}))
+	v, _ = a.Get(1)
+	assert.Equal(t, common.V128{Lo: 0x2345}, v)
+	_, err = a.Get(3)
+	assert.ErrorIs(t, err, ErrArrayOutOfBounds)
+	assert.Error(t, a.SetRef(0, nil))
+
+	// a guest controlled length cannot exhaust the host's memory
+	_, err = NewArray(types, 2, 1<<31, common.V128{})
+	assert.ErrorIs(t, err, ErrArrayTooLarge)
+
+	refs, err := NewRefArray(types, 3, 2, NewI31(7))
+	require.NoError(t, err)
+	ref, err = refs.GetRef(1)
+	require.NoError(t, err)
+	assert.Equal(t, NewI31(7), ref)
+	require.NoError(t, refs.SetRef(1, nil))
+	ref, _ = refs.GetRef(1)
+	assert.Nil(t, ref)
+	_, err = refs.Get(0)
+	assert.Error(t, err)
+	_, err = NewArray(types, 3, 1, common.V128{})
+	assert.Error(t, err)
+
+	vecs, err := NewArray(types, 4, 1, common.V128{Lo: 1, Hi: 2})
+	require.NoError(t, err)
+	v, _ = vecs.Get(0)
+	assert.Equal(t, common.V128{Lo: 1, Hi: 2}, v)
+
+	i := NewI31(0xFFFFFFFF)
+	assert.Equal(t, int32(-1), i.GetS())
+	assert.Equal(t, uint32(0x7FFFFFFF), i.GetU())
+
+	assert.True(t, RefTest(types, s, common.RefType(0, false)))
+	assert.True(t, RefTest(types, s, common.RefType(1, false)))
+	assert.False(t, RefTest(types, s, common.RefType(2, false)))
+	assert.True(t, RefTest(types, s, common.ValTypeStructRef))
+	assert.True(t, RefTest(types, a, common.ValTypeEqRef))
+	assert.False(t, RefTest(types, a, common.ValTypeStructRef))
+	assert.True(t, RefTest(types, i, common.ValTypeI31Ref))
+	assert.False(t, RefTest(types, i, common.ValTypeExternRef))
+	ext := ToExternRef(7)
+	assert.True(t, RefTest(types, ext, common.ValTypeExternRef))
+	assert.True(t, RefTest(types, ext, common.RefType(common.HeapTypeExtern, false)))
+	assert.False(t, RefTest(types, ext, common.ValTypeAnyRef))
+	assert.True(t, RefTest(types, nil, common.ValTypeAnyRef))
+	assert.False(t, RefTest(types, nil, common.RefType(common.HeapTypeAny, false)))
+	fn := &FuncRef{Func: 3, Type: 6}
+	assert.True(t, RefTest(types, fn, common.ValTypeFuncRef))
+	assert.True(t, RefTest(types, fn, common.RefType(5, false)))
+	assert.False(t, RefTest(types, fn, common.RefType(0, false)))
+	assert.False(t, RefTest(types, fn, common.ValTypeAnyRef))
+	assert.False(t, RefTest(types, fn, common.ValTypeExternRef))
+
+	base, err := NewStruct(types, 0, nil)
+	require.NoError(t, err)
+	assert.NoError(t, RefCast(types, s, common.RefType(0, true)))
+	assert.ErrorIs(t, RefCast(types, base, common.RefType(1, true)), ErrCastFailure)
+
+	// references and numbers share the operand stack
+	stack := &OperandStack{}
+	stack.pushRef(s)
+	stack.pushU64(3)
+	assert.Equal(t, uint64(3), stack.popU64())
+	assert.Same(t, s, stack.popRef())
+}
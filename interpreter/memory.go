@@ -0,0 +1,449 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+const (
+	PageSize   = 65536
+	MaxPages   = 65536   // of a 32-bit memory
+	MaxPages64 = 1 << 48 // of a memory64 memory
+)
+
+var (
+	ErrOutOfBounds       = errors.New("out of bounds memory access")
+	ErrUnalignedAtomic   = errors.New("unaligned atomic")
+	ErrExpectedShared    = errors.New("expected shared memory")
+	ErrInvalidLimits     = errors.New("invalid memory limits")
+	errInvalidAtomicSize = errors.New("invalid atomic access size")
+)
+
+// Results of memory.atomic.wait32 and memory.atomic.wait64.
+const (
+	WaitOK       = 0 // woken by memory.atomic.notify
+	WaitNotEqual = 1 // the value did not match the expected one
+	WaitTimedOut = 2
+)
+
+// RMWOp is the operation of an atomic read-modify-write instruction.
+type RMWOp byte
+
+const (
+	RMWAdd RMWOp = iota
+	RMWSub
+	RMWAnd
+	RMWOr
+	RMWXor
+	RMWXchg
+)
+
+// Memory is a linear memory. A shared memory may be used by instances
+// running on different goroutines: every access holds mu for reading, so
+// Grow can move the contents while no access is in flight.
+//
+// Atomic accesses use sync/atomic on the memory's bytes in place, which
+// matches wasm's little endian layout on little endian hosts only.
+//
+// Non-atomic accesses (Read, Write and the bulk operations) are plain byte
+// copies under the read lock, so on a shared memory they race with each
+// other and with atomic accesses to the same bytes, just as wasm's plain
+// loads and stores do: wasm gives racy accesses no ordering and allows torn
+// values. The Go race detector reports such races, so tests running under
+// -race keep plain and atomic accesses to the same bytes apart.
+type Memory struct {
+	mu     sync.RWMutex
+	data   []byte
+	max    uint64 // pages
+	shared bool
+	is64   bool
+
+	waitMu  sync.Mutex
+	waiters map[uint64][]chan struct{} // by address, in arrival order
+}
+
+// NewMemory allocates a memory of limits.Min pages. It fails without
+// allocating if the limits are inconsistent or the initial size does not
+// fit in the host's address space.
+func NewMemory(limits *common.Limits) (*Memory, error) {
+	mem := &Memory{
+		max:     MaxPages,
+		shared:  limits.Shared(),
+		is64:    limits.Is64(),
+		waiters: map[uint64][]chan struct{}{},
+	}
+	if mem.is64 {
+		mem.max = MaxPages64
+	}
+	if limits.HasMax() {
+		if limits.Min > limits.Max {
+			return nil, fmt.Errorf("%w: min %d > max %d", ErrInvalidLimits, limits.Min, limits.Max)
+		}
+		if limits.Max < mem.max {
+			mem.max = limits.Max
+		}
+	}
+	if limits.Min > mem.max {
+		return nil, fmt.Errorf("%w: min %d > %d pages", ErrInvalidLimits, limits.Min, mem.max)
+	}
+	if limits.Min > math.MaxInt/PageSize {
+		return nil, fmt.Errorf("%w: %d pages do not fit in memory", ErrInvalidLimits, limits.Min)
+	}
+	mem.data = make([]byte, limits.Min*PageSize)
+	return mem, nil
+}
+
+// Shared reports whether the memory was declared shared.
+func (m *Memory) Shared() bool {
+	return m.shared
+}
+
+// Is64 reports whether the memory is a memory64 memory, whose addresses,
+// memory.size and memory.grow use i64 instead of i32.
+func (m *Memory) Is64() bool {
+	return m.is64
+}
+
+// Size returns the size of the memory in pages.
+func (m *Memory) Size() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return uint64(len(m.data) / PageSize)
+}
+
+// Grow grows the memory by delta pages and returns its previous size, or
+// false if that would exceed its maximum.
+func (m *Memory) Grow(delta uint64) (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old := uint64(len(m.data) / PageSize)
+	if delta > m.max-old || old+delta > math.MaxInt/PageSize {
+		return old, false
+	}
+	if delta != 0 {
+		data := make([]byte, (old+delta)*PageSize)
+		copy(data, m.data)
+		m.data = data
+	}
+	return old, true
+}
+
+// Read copies len(buf) bytes at addr into buf. It is not atomic, see Memory.
+func (m *Memory) Read(addr uint64, buf []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkBounds(addr, uint64(len(buf))); err != nil {
+		return err
+	}
+	copy(buf, m.data[addr:])
+	return nil
+}
+
+// Write copies buf to addr. It is not atomic, see Memory.
+func (m *Memory) Write(addr uint64, buf []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkBounds(addr, uint64(len(buf))); err != nil {
+		return err
+	}
+	copy(m.data[addr:], buf)
+	return nil
+}
+
+// Fill implements memory.fill: it sets n bytes at addr to val. Like the
+// other bulk memory operations it checks the whole range before writing
+// anything, so an out of bounds fill leaves the memory unchanged.
+func (m *Memory) Fill(addr, n uint64, val byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkBounds(addr, n); err != nil {
+		return err
+	}
+	buf := m.data[addr : addr+n]
+	for i := range buf {
+		buf[i] = val
+	}
+	return nil
+}
+
+// Copy implements memory.copy: it copies n bytes at srcAddr in src, which
+// may be m itself, to dst in m. Overlapping ranges are copied as if through
+// an intermediate buffer.
+func (m *Memory) Copy(dst uint64, src *Memory, srcAddr, n uint64) error {
+	if src != m {
+		// read first rather than holding both locks, which could deadlock
+		// with a copy the other way round
+		src.mu.RLock()
+		err := src.checkBounds(srcAddr, n)
+		var buf []byte
+		if err == nil {
+			buf = append(buf, src.data[srcAddr:srcAddr+n]...)
+		}
+		src.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		return m.Write(dst, buf)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkBounds(srcAddr, n); err != nil {
+		return err
+	}
+	if err := m.checkBounds(dst, n); err != nil {
+		return err
+	}
+	copy(m.data[dst:dst+n], m.data[srcAddr:srcAddr+n])
+	return nil
+}
+
+// Init implements memory.init: it copies n bytes at offset in seg to dst.
+func (m *Memory) Init(dst uint64, seg *DataSegment, offset, n uint64) error {
+	data, err := seg.Slice(offset, n)
+	if err != nil {
+		return err
+	}
+	return m.Write(dst, data)
+}
+
+func (m *Memory) checkBounds(addr, n uint64) error {
+	if n > uint64(len(m.data)) || addr > uint64(len(m.data))-n {
+		return ErrOutOfBounds
+	}
+	return nil
+}
+
+// checkAtomic checks an atomic access of size bytes at addr. The caller
+// holds mu.
+func (m *Memory) checkAtomic(addr uint64, size int) error {
+	switch size {
+	case 1, 2, 4, 8:
+	default:
+		return errInvalidAtomicSize
+	}
+	if err := m.checkBounds(addr, uint64(size)); err != nil {
+		return err
+	}
+	if addr%uint64(size) != 0 {
+		return ErrUnalignedAtomic
+	}
+	return nil
+}
+
+func (m *Memory) ptr32(addr uint64) *uint32 {
+	return (*uint32)(unsafe.Pointer(&m.data[addr]))
+}
+
+func (m *Memory) ptr64(addr uint64) *uint64 {
+	return (*uint64)(unsafe.Pointer(&m.data[addr]))
+}
+
+// AtomicLoad loads size bytes at addr, zero-extended.
+func (m *Memory) AtomicLoad(addr uint64, size int) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkAtomic(addr, size); err != nil {
+		return 0, err
+	}
+	return m.load(addr, size), nil
+}
+
+func (m *Memory) load(addr uint64, size int) uint64 {
+	switch size {
+	case 8:
+		return atomic.LoadUint64(m.ptr64(addr))
+	case 4:
+		return uint64(atomic.LoadUint32(m.ptr32(addr)))
+	}
+	word := atomic.LoadUint32(m.ptr32(addr &^ 3))
+	return uint64(word>>((addr&3)*8)) & (1<<(size*8) - 1)
+}
+
+// AtomicStore stores the low size bytes of val at addr.
+func (m *Memory) AtomicStore(addr uint64, size int, val uint64) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkAtomic(addr, size); err != nil {
+		return err
+	}
+	switch size {
+	case 8:
+		atomic.StoreUint64(m.ptr64(addr), val)
+	case 4:
+		atomic.StoreUint32(m.ptr32(addr), uint32(val))
+	default:
+		m.update(addr, size, func(uint64) uint64 { return val })
+	}
+	return nil
+}
+
+// AtomicRMW applies op with val to the size bytes at addr and returns their
+// previous value.
+func (m *Memory) AtomicRMW(addr uint64, size int, op RMWOp, val uint64) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkAtomic(addr, size); err != nil {
+		return 0, err
+	}
+	return m.update(addr, size, func(old uint64) uint64 {
+		switch op {
+		case RMWAdd:
+			return old + val
+		case RMWSub:
+			return old - val
+		case RMWAnd:
+			return old & val
+		case RMWOr:
+			return old | val
+		case RMWXor:
+			return old ^ val
+		default:
+			return val
+		}
+	}), nil
+}
+
+// AtomicCmpxchg replaces the size bytes at addr with replacement if they
+// equal expected, and returns their previous value. expected is compared
+// after wrapping it to size bytes.
+func (m *Memory) AtomicCmpxchg(addr uint64, size int, expected, replacement uint64) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.checkAtomic(addr, size); err != nil {
+		return 0, err
+	}
+	if size < 8 {
+		expected &= 1<<(size*8) - 1
+	}
+	return m.update(addr, size, func(old uint64) uint64 {
+		if old == expected {
+			return replacement
+		}
+		return old
+	}), nil
+}
+
+// update atomically replaces the size bytes at addr with f of their value
+// and returns the old value. Accesses narrower than 32 bits go through the
+// aligned word holding them.
+func (m *Memory) update(addr uint64, size int, f func(old uint64) uint64) uint64 {
+	if size == 8 {
+		p := m.ptr64(addr)
+		for {
+			old := atomic.LoadUint64(p)
+			if atomic.CompareAndSwapUint64(p, old, f(old)) {
+				return old
+			}
+		}
+	}
+
+	p := m.ptr32(addr &^ 3)
+	shift := (addr & 3) * 8
+	mask := uint32(1<<(size*8) - 1)
+	for {
+		word := atomic.LoadUint32(p)
+		old := word >> shift & mask
+		replaced := word&^(mask<<shift) | (uint32(f(uint64(old)))&mask)<<shift
+		if atomic.CompareAndSwapUint32(p, word, replaced) {
+			return uint64(old)
+		}
+	}
+}
+
+// Wait32 implements memory.atomic.wait32: if the i32 at addr equals
+// expected, it blocks until notified or until timeout nanoseconds have
+// passed. A negative timeout never expires.
+func (m *Memory) Wait32(addr uint64, expected uint32, timeout int64) (uint32, error) {
+	return m.wait(addr, 4, uint64(expected), timeout)
+}
+
+// Wait64 implements memory.atomic.wait64, see Wait32.
+func (m *Memory) Wait64(addr uint64, expected uint64, timeout int64) (uint32, error) {
+	return m.wait(addr, 8, expected, timeout)
+}
+
+func (m *Memory) wait(addr uint64, size int, expected uint64, timeout int64) (uint32, error) {
+	if !m.shared {
+		return 0, ErrExpectedShared
+	}
+
+	// holding waitMu between the comparison and queueing up keeps a notify
+	// from slipping in between
+	m.waitMu.Lock()
+	val, err := m.AtomicLoad(addr, size)
+	if err != nil {
+		m.waitMu.Unlock()
+		return 0, err
+	}
+	if val != expected {
+		m.waitMu.Unlock()
+		return WaitNotEqual, nil
+	}
+	woken := make(chan struct{})
+	m.waiters[addr] = append(m.waiters[addr], woken)
+	m.waitMu.Unlock()
+
+	if timeout < 0 {
+		<-woken
+		return WaitOK, nil
+	}
+	timer := time.NewTimer(time.Duration(timeout))
+	defer timer.Stop()
+	select {
+	case <-woken:
+		return WaitOK, nil
+	case <-timer.C:
+	}
+
+	m.waitMu.Lock()
+	defer m.waitMu.Unlock()
+	queue := m.waiters[addr]
+	for i, ch := range queue {
+		if ch == woken {
+			m.setWaiters(addr, append(queue[:i:i], queue[i+1:]...))
+			return WaitTimedOut, nil
+		}
+	}
+	// a notify dequeued us after the timer fired
+	return WaitOK, nil
+}
+
+// Notify implements memory.atomic.notify: it wakes up to count waiters on
+// addr, oldest first, and returns how many it woke.
+func (m *Memory) Notify(addr uint64, count uint32) (uint32, error) {
+	m.mu.RLock()
+	err := m.checkAtomic(addr, 4)
+	m.mu.RUnlock()
+	if err != nil || !m.shared {
+		return 0, err
+	}
+
+	m.waitMu.Lock()
+	defer m.waitMu.Unlock()
+	queue := m.waiters[addr]
+	n := len(queue)
+	if uint64(n) > uint64(count) {
+		n = int(count)
+	}
+	for _, ch := range queue[:n] {
+		close(ch)
+	}
+	m.setWaiters(addr, queue[n:])
+	return uint32(n), nil
+}
+
+func (m *Memory) setWaiters(addr uint64, queue []chan struct{}) {
+	if len(queue) == 0 {
+		delete(m.waiters, addr)
+		return
+	}
+	m.waiters[addr] = queue
+}
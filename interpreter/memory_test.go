@@ -0,0 +1,213 @@
+package interpreter
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemory(t *testing.T, limits common.Limits) *Memory {
+	mem, err := NewMemory(&limits)
+	require.NoError(t, err)
+	return mem
+}
+
+func newSharedMemory(t *testing.T) *Memory {
+	return newMemory(t, common.Limits{Tag: common.LimitsFlagSharedHasMax, Min: 1, Max: 2})
+}
+
+func TestMemoryAtomics(t *testing.T) {
+	mem := newSharedMemory(t)
+
+	require.NoError(t, mem.AtomicStore(8, 8, 0x1122334455667788))
+	val, err := mem.AtomicLoad(10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x5566), val)
+
+	old, err := mem.AtomicRMW(9, 1, RMWAdd, 0x1FF)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x77), old)
+	val, _ = mem.AtomicLoad(8, 8)
+	assert.Equal(t, uint64(0x1122334455667688), val, "only the addressed byte wraps")
+
+	old, err = mem.AtomicCmpxchg(12, 4, 0xFFFF_FFFF_1122_3344, 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x11223344), old)
+	val, _ = mem.AtomicLoad(12, 4)
+	assert.Equal(t, uint64(7), val)
+
+	old, _ = mem.AtomicCmpxchg(12, 4, 8, 9)
+	assert.Equal(t, uint64(7), old)
+	val, _ = mem.AtomicLoad(12, 4)
+	assert.Equal(t, uint64(7), val)
+
+	_, err = mem.AtomicLoad(2, 4)
+	assert.ErrorIs(t, err, ErrUnalignedAtomic)
+	_, err = mem.AtomicLoad(PageSize-4, 8)
+	assert.ErrorIs(t, err, ErrOutOfBounds)
+	assert.ErrorIs(t, mem.AtomicStore(1<<64-4, 4, 0), ErrOutOfBounds)
+
+	pages, ok := mem.Grow(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), pages)
+	_, ok = mem.Grow(1)
+	assert.False(t, ok)
+	val, _ = mem.AtomicLoad(12, 4)
+	assert.Equal(t, uint64(7), val, "grow keeps the contents")
+}
+
+func TestMemoryConcurrentRMW(t *testing.T) {
+	mem := newSharedMemory(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_, _ = mem.AtomicRMW(0, 1, RMWAdd, 1)
+				_, _ = mem.AtomicRMW(1, 1, RMWAdd, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	val, _ := mem.AtomicLoad(0, 2)
+	assert.Equal(t, uint64(8000%256*0x101), val)
+}
+
+func TestMemoryWaitNotify(t *testing.T) {
+	mem := newSharedMemory(t)
+
+	res, err := mem.Wait32(0, 1, -1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(WaitNotEqual), res)
+
+	res, err = mem.Wait64(8, 0, int64(time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(WaitTimedOut), res)
+
+	done := make(chan uint32, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			res, _ := mem.Wait32(4, 0, -1)
+			done <- res
+		}()
+	}
+	// wait until both are queued
+	for {
+		mem.waitMu.Lock()
+		n := len(mem.waiters[4])
+		mem.waitMu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	woken, err := mem.Notify(4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), woken)
+	assert.Equal(t, uint32(WaitOK), <-done)
+	woken, _ = mem.Notify(4, 10)
+	assert.Equal(t, uint32(1), woken)
+	assert.Equal(t, uint32(WaitOK), <-done)
+
+	_, err = mem.Notify(2, 1)
+	assert.ErrorIs(t, err, ErrUnalignedAtomic)
+
+	unshared := newMemory(t, common.Limits{Min: 1})
+	_, err = unshared.Wait32(0, 0, 0)
+	assert.ErrorIs(t, err, ErrExpectedShared)
+	woken, err = unshared.Notify(0, 1)
+	assert.NoError(t, err)
+	assert.Zero(t, woken)
+}
+
+func TestMemory64(t *testing.T) {
+	mem := newMemory(t, common.Limits{Tag: common.LimitsFlagI64NoMax})
+	assert.True(t, mem.Is64())
+	assert.Equal(t, uint64(0), mem.Size())
+
+	_, ok := mem.Grow(1 << 47)
+	assert.False(t, ok, "too large to address")
+	pages, ok := mem.Grow(1)
+	assert.True(t, ok)
+	assert.Zero(t, pages)
+
+	assert.ErrorIs(t, mem.Write(1<<32, []byte{1}), ErrOutOfBounds)
+	assert.NoError(t, mem.Write(PageSize-1, []byte{1}))
+}
+
+func TestNewMemoryLimits(t *testing.T) {
+	for _, limits := range []common.Limits{
+		{Tag: common.LimitsFlagI64NoMax, Min: 1 << 48}, // wraps to 0 bytes
+		{Tag: common.LimitsFlagI64NoMax, Min: 1 << 47}, // beyond any slice
+		{Tag: common.LimitsFlagI64NoMax, Min: MaxPages64 + 1},
+		{Min: MaxPages + 1},
+		{Tag: common.LimitsFlagHasMax, Min: 2, Max: 1},
+	} {
+		_, err := NewMemory(&limits)
+		assert.ErrorIs(t, err, ErrInvalidLimits, "%+v", limits)
+	}
+
+	mem := newMemory(t, common.Limits{Tag: common.LimitsFlagHasMax, Min: 1, Max: 1})
+	_, ok := mem.Grow(1)
+	assert.False(t, ok)
+}
+
+func TestMemoryBulk(t *testing.T) {
+	mem := newMemory(t, common.Limits{Min: 1})
+	require.NoError(t, mem.Write(0, []byte{1, 2, 3, 4, 5}))
+
+	// overlapping copies in both directions
+	require.NoError(t, mem.Copy(1, mem, 0, 4))
+	buf := make([]byte, 6)
+	require.NoError(t, mem.Read(0, buf))
+	assert.Equal(t, []byte{1, 1, 2, 3, 4, 0}, buf)
+	require.NoError(t, mem.Copy(0, mem, 2, 4))
+	require.NoError(t, mem.Read(0, buf))
+	assert.Equal(t, []byte{2, 3, 4, 0, 4, 0}, buf)
+
+	require.NoError(t, mem.Fill(1, 2, 0xAA))
+	require.NoError(t, mem.Read(0, buf))
+	assert.Equal(t, []byte{2, 0xAA, 0xAA, 0, 4, 0}, buf)
+
+	// an out of bounds range traps before anything is written
+	assert.ErrorIs(t, mem.Fill(PageSize-2, 3, 0xFF), ErrOutOfBounds)
+	assert.ErrorIs(t, mem.Copy(PageSize-2, mem, 0, 3), ErrOutOfBounds)
+	assert.ErrorIs(t, mem.Copy(0, mem, PageSize-2, 3), ErrOutOfBounds)
+	require.NoError(t, mem.Read(PageSize-2, buf[:2]))
+	assert.Equal(t, []byte{0, 0}, buf[:2])
+	require.NoError(t, mem.Read(0, buf[:1]))
+	assert.Equal(t, []byte{2}, buf[:1])
+	assert.ErrorIs(t, mem.Fill(1<<64-1, 2, 0), ErrOutOfBounds)
+	assert.NoError(t, mem.Fill(PageSize, 0, 0), "empty ranges may end at the bound")
+	assert.ErrorIs(t, mem.Fill(PageSize+1, 0, 0), ErrOutOfBounds)
+
+	other := newMemory(t, common.Limits{Min: 1})
+	require.NoError(t, other.Copy(10, mem, 0, 3))
+	require.NoError(t, other.Read(10, buf[:3]))
+	assert.Equal(t, []byte{2, 0xAA, 0xAA}, buf[:3])
+	assert.ErrorIs(t, other.Copy(PageSize-1, mem, 0, 2), ErrOutOfBounds)
+
+	seg := NewDataSegment([]byte("hello"))
+	require.NoError(t, mem.Init(100, seg, 1, 3))
+	require.NoError(t, mem.Read(100, buf[:4]))
+	assert.Equal(t, []byte("ell\x00"), buf[:4])
+	assert.ErrorIs(t, mem.Init(100, seg, 3, 3), ErrOutOfBounds)
+	assert.ErrorIs(t, mem.Init(PageSize-1, seg, 0, 2), ErrOutOfBounds)
+	seg.Drop()
+	assert.ErrorIs(t, mem.Init(100, seg, 0, 1), ErrOutOfBounds)
+	assert.NoError(t, mem.Init(100, seg, 0, 0))
+
+	elems := NewElemSegment([]any{NewI31(1), nil})
+	refs, err := elems.Slice(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []any{nil}, refs)
+	elems.Drop()
+	_, err = elems.Slice(0, 1)
+	assert.ErrorIs(t, err, ErrTableOutOfBounds)
+}
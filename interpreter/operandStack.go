@@ -1,18 +1,32 @@
 package interpreter
 
-import "math"
+import (
+	"math"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+// Value is an operand. A number or vector is held in Num, 128 bits wide so
+// a v128 fits, every other type only using the low 64 bits. A reference is
+// held in Ref: nil for null, otherwise a GC object, a *FuncRef, an
+// *ExternRef or, for an exnref, an *Exception.
+type Value struct {
+	Num common.V128
+	Ref any
+}
 
+// OperandStack holds one value per slot.
 type OperandStack struct {
-	slots []uint64
+	slots []Value
 }
 
-// pushU64
-func (s *OperandStack) pushU64(val uint64) {
+// push
+func (s *OperandStack) push(val Value) {
 	s.slots = append(s.slots, val)
 }
 
-// popU64
-func (s *OperandStack) popU64() uint64 {
+// pop
+func (s *OperandStack) pop() Value {
 	if len(s.slots) == 0 {
 		panic("operand stack is empty")
 	}
@@ -21,6 +35,36 @@ func (s *OperandStack) popU64() uint64 {
 	return val
 }
 
+// pushV128
+func (s *OperandStack) pushV128(val common.V128) {
+	s.push(Value{Num: val})
+}
+
+// popV128
+func (s *OperandStack) popV128() common.V128 {
+	return s.pop().Num
+}
+
+// pushRef
+func (s *OperandStack) pushRef(ref any) {
+	s.push(Value{Ref: ref})
+}
+
+// popRef
+func (s *OperandStack) popRef() any {
+	return s.pop().Ref
+}
+
+// pushU64
+func (s *OperandStack) pushU64(val uint64) {
+	s.pushV128(common.V128{Lo: val})
+}
+
+// popU64
+func (s *OperandStack) popU64() uint64 {
+	return s.popV128().Lo
+}
+
 // pushS64
 func (s *OperandStack) pushS64(val int64) {
 	s.pushU64(uint64(val))
@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+var ErrNotExternRef = errors.New("not an externref")
+
+// ExternRef is a non-null externref: a host value passed into the guest.
+// The guest cannot look inside it, only store it, test it for null and pass
+// it back to the host.
+type ExternRef struct {
+	Value any
+}
+
+// FuncRef is a non-null funcref: a function of the module and the index of
+// its type, which ref.test and ref.cast check the reference against.
+type FuncRef struct {
+	Func common.FuncIdx
+	Type common.TypeIdx
+}
+
+// ToExternRef returns the reference a guest sees for the host value v, in
+// the form RefTest takes references: nil, the null reference, for nil.
+func ToExternRef(v any) any {
+	if v == nil {
+		return nil
+	}
+	return &ExternRef{Value: v}
+}
+
+// FromExternRef returns the host value of an externref handed back by the
+// guest, nil for the null reference.
+func FromExternRef(ref any) (any, error) {
+	switch r := ref.(type) {
+	case nil:
+		return nil, nil
+	case *ExternRef:
+		return r.Value, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrNotExternRef, ref)
+}
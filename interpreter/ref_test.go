@@ -0,0 +1,29 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternRef(t *testing.T) {
+	type handle struct{ fd int }
+	host := &handle{fd: 3}
+
+	ref := ToExternRef(host)
+	v, err := FromExternRef(ref)
+	assert.NoError(t, err)
+	assert.Same(t, host, v)
+
+	// 0 is a host value like any other, not null
+	assert.NotNil(t, ToExternRef(0))
+	assert.Nil(t, ToExternRef(nil))
+	v, err = FromExternRef(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = FromExternRef(NewI31(1))
+	assert.ErrorIs(t, err, ErrNotExternRef)
+	_, err = FromExternRef(&FuncRef{Func: 0, Type: 0})
+	assert.ErrorIs(t, err, ErrNotExternRef)
+}
@@ -0,0 +1,54 @@
+package interpreter
+
+import "errors"
+
+var ErrTableOutOfBounds = errors.New("out of bounds table access")
+
+// DataSegment is a data segment of an instance. data.drop drops it, as
+// does instantiation once an active segment is copied to memory: from then
+// on it is empty, and only memory.init of zero bytes at offset 0 succeeds.
+type DataSegment struct {
+	data []byte
+}
+
+func NewDataSegment(data []byte) *DataSegment {
+	return &DataSegment{data: data}
+}
+
+// Drop implements data.drop.
+func (s *DataSegment) Drop() {
+	s.data = nil
+}
+
+// Slice returns the n bytes at offset, or ErrOutOfBounds.
+func (s *DataSegment) Slice(offset, n uint64) ([]byte, error) {
+	if n > uint64(len(s.data)) || offset > uint64(len(s.data))-n {
+		return nil, ErrOutOfBounds
+	}
+	return s.data[offset : offset+n], nil
+}
+
+// ElemSegment is an element segment of an instance, holding references as
+// RefTest takes them. elem.drop drops it, as does instantiation for active
+// and declarative segments.
+type ElemSegment struct {
+	elems []any
+}
+
+func NewElemSegment(elems []any) *ElemSegment {
+	return &ElemSegment{elems: elems}
+}
+
+// Drop implements elem.drop.
+func (s *ElemSegment) Drop() {
+	s.elems = nil
+}
+
+// Slice returns the n references at offset, or ErrTableOutOfBounds, for
+// table.init.
+func (s *ElemSegment) Slice(offset, n uint64) ([]any, error) {
+	if n > uint64(len(s.elems)) || offset > uint64(len(s.elems))-n {
+		return nil, ErrTableOutOfBounds
+	}
+	return s.elems[offset : offset+n], nil
+}
@@ -1,7 +1,51 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/decode"
+)
+
+const usage = `usage: wasm <command> [arguments]
+
+commands:
+  objdump   print information about a module
+`
 
 func main() {
-	fmt.Println("Hello, World!")
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the process exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "objdump":
+		return objdump(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "wasm: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func readModule(path string) (*decode.Module, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	module, err := decode.DecodeModule(common.NewSliceBytes(buf))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return module, nil
 }
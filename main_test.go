@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjdump(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"objdump", "-h", "-x", "-d", "testdata/wasm/ch01_hw.wasm"}, &stdout, &stderr)
+	assert.Equal(t, 0, status, stderr.String())
+
+	out := stdout.String()
+	assert.Contains(t, out, "     Code start=0x0000009d end=0x000004f3 (size=0x00000456) count: 11")
+	assert.Contains(t, out, "  export[3]: func[1]=main")
+	assert.Contains(t, out, "func[1]:")
+	assert.Contains(t, out, " 0000a3: 23 80 80 80 80 00           |  global.get 0")
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run([]string{"frobnicate"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "unknown command")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+func objdump(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("objdump", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: wasm objdump [-h] [-x] [-d] file.wasm...")
+		flags.PrintDefaults()
+	}
+	headers := flags.Bool("h", false, "print section headers")
+	details := flags.Bool("x", false, "print section details")
+	disassemble := flags.Bool("d", false, "disassemble function bodies")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if !*headers && !*details && !*disassemble {
+		*headers = true
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		module, err := readModule(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "wasm objdump: %v\n", err)
+			status = 1
+			continue
+		}
+
+		fmt.Fprintf(stdout, "\n%s:\tfile format wasm 0x%x\n\n", path, module.Version)
+		if *headers {
+			fmt.Fprintln(stdout, module.DisplayHeaders())
+		}
+		if *details {
+			fmt.Fprintln(stdout, module.DisplayDetails())
+		}
+		if *disassemble {
+			str, err := module.DisplayDisassembly()
+			fmt.Fprintln(stdout, str)
+			if err != nil {
+				fmt.Fprintf(stderr, "wasm objdump: %s: %v\n", path, err)
+				status = 1
+			}
+		}
+	}
+	return status
+}
@@ -0,0 +1,162 @@
+package opcode
+
+import "fmt"
+
+// PrefixFE is the prefix of the atomic instructions of the threads proposal,
+// which follow it with a u32 sub-opcode.
+const PrefixFE = 0xFE
+
+// Sub-opcodes following PrefixFE.
+const (
+	MemoryAtomicNotify     = 0x00 // memory.atomic.notify
+	MemoryAtomicWait32     = 0x01 // memory.atomic.wait32
+	MemoryAtomicWait64     = 0x02 // memory.atomic.wait64
+	AtomicFence            = 0x03 // atomic.fence
+	I32AtomicLoad          = 0x10 // i32.atomic.load
+	I64AtomicLoad          = 0x11 // i64.atomic.load
+	I32AtomicLoad8U        = 0x12 // i32.atomic.load8_u
+	I32AtomicLoad16U       = 0x13 // i32.atomic.load16_u
+	I64AtomicLoad8U        = 0x14 // i64.atomic.load8_u
+	I64AtomicLoad16U       = 0x15 // i64.atomic.load16_u
+	I64AtomicLoad32U       = 0x16 // i64.atomic.load32_u
+	I32AtomicStore         = 0x17 // i32.atomic.store
+	I64AtomicStore         = 0x18 // i64.atomic.store
+	I32AtomicStore8        = 0x19 // i32.atomic.store8
+	I32AtomicStore16       = 0x1A // i32.atomic.store16
+	I64AtomicStore8        = 0x1B // i64.atomic.store8
+	I64AtomicStore16       = 0x1C // i64.atomic.store16
+	I64AtomicStore32       = 0x1D // i64.atomic.store32
+	I32AtomicRmwAdd        = 0x1E // i32.atomic.rmw.add
+	I64AtomicRmwAdd        = 0x1F // i64.atomic.rmw.add
+	I32AtomicRmw8AddU      = 0x20 // i32.atomic.rmw8.add_u
+	I32AtomicRmw16AddU     = 0x21 // i32.atomic.rmw16.add_u
+	I64AtomicRmw8AddU      = 0x22 // i64.atomic.rmw8.add_u
+	I64AtomicRmw16AddU     = 0x23 // i64.atomic.rmw16.add_u
+	I64AtomicRmw32AddU     = 0x24 // i64.atomic.rmw32.add_u
+	I32AtomicRmwSub        = 0x25 // i32.atomic.rmw.sub
+	I64AtomicRmwSub        = 0x26 // i64.atomic.rmw.sub
+	I32AtomicRmw8SubU      = 0x27 // i32.atomic.rmw8.sub_u
+	I32AtomicRmw16SubU     = 0x28 // i32.atomic.rmw16.sub_u
+	I64AtomicRmw8SubU      = 0x29 // i64.atomic.rmw8.sub_u
+	I64AtomicRmw16SubU     = 0x2A // i64.atomic.rmw16.sub_u
+	I64AtomicRmw32SubU     = 0x2B // i64.atomic.rmw32.sub_u
+	I32AtomicRmwAnd        = 0x2C // i32.atomic.rmw.and
+	I64AtomicRmwAnd        = 0x2D // i64.atomic.rmw.and
+	I32AtomicRmw8AndU      = 0x2E // i32.atomic.rmw8.and_u
+	I32AtomicRmw16AndU     = 0x2F // i32.atomic.rmw16.and_u
+	I64AtomicRmw8AndU      = 0x30 // i64.atomic.rmw8.and_u
+	I64AtomicRmw16AndU     = 0x31 // i64.atomic.rmw16.and_u
+	I64AtomicRmw32AndU     = 0x32 // i64.atomic.rmw32.and_u
+	I32AtomicRmwOr         = 0x33 // i32.atomic.rmw.or
+	I64AtomicRmwOr         = 0x34 // i64.atomic.rmw.or
+	I32AtomicRmw8OrU       = 0x35 // i32.atomic.rmw8.or_u
+	I32AtomicRmw16OrU      = 0x36 // i32.atomic.rmw16.or_u
+	I64AtomicRmw8OrU       = 0x37 // i64.atomic.rmw8.or_u
+	I64AtomicRmw16OrU      = 0x38 // i64.atomic.rmw16.or_u
+	I64AtomicRmw32OrU      = 0x39 // i64.atomic.rmw32.or_u
+	I32AtomicRmwXor        = 0x3A // i32.atomic.rmw.xor
+	I64AtomicRmwXor        = 0x3B // i64.atomic.rmw.xor
+	I32AtomicRmw8XorU      = 0x3C // i32.atomic.rmw8.xor_u
+	I32AtomicRmw16XorU     = 0x3D // i32.atomic.rmw16.xor_u
+	I64AtomicRmw8XorU      = 0x3E // i64.atomic.rmw8.xor_u
+	I64AtomicRmw16XorU     = 0x3F // i64.atomic.rmw16.xor_u
+	I64AtomicRmw32XorU     = 0x40 // i64.atomic.rmw32.xor_u
+	I32AtomicRmwXchg       = 0x41 // i32.atomic.rmw.xchg
+	I64AtomicRmwXchg       = 0x42 // i64.atomic.rmw.xchg
+	I32AtomicRmw8XchgU     = 0x43 // i32.atomic.rmw8.xchg_u
+	I32AtomicRmw16XchgU    = 0x44 // i32.atomic.rmw16.xchg_u
+	I64AtomicRmw8XchgU     = 0x45 // i64.atomic.rmw8.xchg_u
+	I64AtomicRmw16XchgU    = 0x46 // i64.atomic.rmw16.xchg_u
+	I64AtomicRmw32XchgU    = 0x47 // i64.atomic.rmw32.xchg_u
+	I32AtomicRmwCmpxchg    = 0x48 // i32.atomic.rmw.cmpxchg
+	I64AtomicRmwCmpxchg    = 0x49 // i64.atomic.rmw.cmpxchg
+	I32AtomicRmw8CmpxchgU  = 0x4A // i32.atomic.rmw8.cmpxchg_u
+	I32AtomicRmw16CmpxchgU = 0x4B // i32.atomic.rmw16.cmpxchg_u
+	I64AtomicRmw8CmpxchgU  = 0x4C // i64.atomic.rmw8.cmpxchg_u
+	I64AtomicRmw16CmpxchgU = 0x4D // i64.atomic.rmw16.cmpxchg_u
+	I64AtomicRmw32CmpxchgU = 0x4E // i64.atomic.rmw32.cmpxchg_u
+)
+
+var namesFE = map[uint32]string{
+	MemoryAtomicNotify:     "memory.atomic.notify",
+	MemoryAtomicWait32:     "memory.atomic.wait32",
+	MemoryAtomicWait64:     "memory.atomic.wait64",
+	AtomicFence:            "atomic.fence",
+	I32AtomicLoad:          "i32.atomic.load",
+	I64AtomicLoad:          "i64.atomic.load",
+	I32AtomicLoad8U:        "i32.atomic.load8_u",
+	I32AtomicLoad16U:       "i32.atomic.load16_u",
+	I64AtomicLoad8U:        "i64.atomic.load8_u",
+	I64AtomicLoad16U:       "i64.atomic.load16_u",
+	I64AtomicLoad32U:       "i64.atomic.load32_u",
+	I32AtomicStore:         "i32.atomic.store",
+	I64AtomicStore:         "i64.atomic.store",
+	I32AtomicStore8:        "i32.atomic.store8",
+	I32AtomicStore16:       "i32.atomic.store16",
+	I64AtomicStore8:        "i64.atomic.store8",
+	I64AtomicStore16:       "i64.atomic.store16",
+	I64AtomicStore32:       "i64.atomic.store32",
+	I32AtomicRmwAdd:        "i32.atomic.rmw.add",
+	I64AtomicRmwAdd:        "i64.atomic.rmw.add",
+	I32AtomicRmw8AddU:      "i32.atomic.rmw8.add_u",
+	I32AtomicRmw16AddU:     "i32.atomic.rmw16.add_u",
+	I64AtomicRmw8AddU:      "i64.atomic.rmw8.add_u",
+	I64AtomicRmw16AddU:     "i64.atomic.rmw16.add_u",
+	I64AtomicRmw32AddU:     "i64.atomic.rmw32.add_u",
+	I32AtomicRmwSub:        "i32.atomic.rmw.sub",
+	I64AtomicRmwSub:        "i64.atomic.rmw.sub",
+	I32AtomicRmw8SubU:      "i32.atomic.rmw8.sub_u",
+	I32AtomicRmw16SubU:     "i32.atomic.rmw16.sub_u",
+	I64AtomicRmw8SubU:      "i64.atomic.rmw8.sub_u",
+	I64AtomicRmw16SubU:     "i64.atomic.rmw16.sub_u",
+	I64AtomicRmw32SubU:     "i64.atomic.rmw32.sub_u",
+	I32AtomicRmwAnd:        "i32.atomic.rmw.and",
+	I64AtomicRmwAnd:        "i64.atomic.rmw.and",
+	I32AtomicRmw8AndU:      "i32.atomic.rmw8.and_u",
+	I32AtomicRmw16AndU:     "i32.atomic.rmw16.and_u",
+	I64AtomicRmw8AndU:      "i64.atomic.rmw8.and_u",
+	I64AtomicRmw16AndU:     "i64.atomic.rmw16.and_u",
+	I64AtomicRmw32AndU:     "i64.atomic.rmw32.and_u",
+	I32AtomicRmwOr:         "i32.atomic.rmw.or",
+	I64AtomicRmwOr:         "i64.atomic.rmw.or",
+	I32AtomicRmw8OrU:       "i32.atomic.rmw8.or_u",
+	I32AtomicRmw16OrU:      "i32.atomic.rmw16.or_u",
+	I64AtomicRmw8OrU:       "i64.atomic.rmw8.or_u",
+	I64AtomicRmw16OrU:      "i64.atomic.rmw16.or_u",
+	I64AtomicRmw32OrU:      "i64.atomic.rmw32.or_u",
+	I32AtomicRmwXor:        "i32.atomic.rmw.xor",
+	I64AtomicRmwXor:        "i64.atomic.rmw.xor",
+	I32AtomicRmw8XorU:      "i32.atomic.rmw8.xor_u",
+	I32AtomicRmw16XorU:     "i32.atomic.rmw16.xor_u",
+	I64AtomicRmw8XorU:      "i64.atomic.rmw8.xor_u",
+	I64AtomicRmw16XorU:     "i64.atomic.rmw16.xor_u",
+	I64AtomicRmw32XorU:     "i64.atomic.rmw32.xor_u",
+	I32AtomicRmwXchg:       "i32.atomic.rmw.xchg",
+	I64AtomicRmwXchg:       "i64.atomic.rmw.xchg",
+	I32AtomicRmw8XchgU:     "i32.atomic.rmw8.xchg_u",
+	I32AtomicRmw16XchgU:    "i32.atomic.rmw16.xchg_u",
+	I64AtomicRmw8XchgU:     "i64.atomic.rmw8.xchg_u",
+	I64AtomicRmw16XchgU:    "i64.atomic.rmw16.xchg_u",
+	I64AtomicRmw32XchgU:    "i64.atomic.rmw32.xchg_u",
+	I32AtomicRmwCmpxchg:    "i32.atomic.rmw.cmpxchg",
+	I64AtomicRmwCmpxchg:    "i64.atomic.rmw.cmpxchg",
+	I32AtomicRmw8CmpxchgU:  "i32.atomic.rmw8.cmpxchg_u",
+	I32AtomicRmw16CmpxchgU: "i32.atomic.rmw16.cmpxchg_u",
+	I64AtomicRmw8CmpxchgU:  "i64.atomic.rmw8.cmpxchg_u",
+	I64AtomicRmw16CmpxchgU: "i64.atomic.rmw16.cmpxchg_u",
+	I64AtomicRmw32CmpxchgU: "i64.atomic.rmw32.cmpxchg_u",
+}
+
+// NameFE returns the mnemonic of a sub-opcode following PrefixFE.
+func NameFE(sub uint32) string {
+	if name, ok := namesFE[sub]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown 0xfe %d>", sub)
+}
+
+// IsValidFE reports whether sub is a known sub-opcode following PrefixFE.
+func IsValidFE(sub uint32) bool {
+	_, ok := namesFE[sub]
+	return ok
+}
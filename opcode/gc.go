@@ -0,0 +1,90 @@
+package opcode
+
+import "fmt"
+
+// PrefixFB is the prefix of the instructions of the GC proposal, which
+// follow it with a u32 sub-opcode.
+const PrefixFB = 0xFB
+
+// Sub-opcodes following PrefixFB.
+const (
+	StructNew        = 0x00 // struct.new
+	StructNewDefault = 0x01 // struct.new_default
+	StructGet        = 0x02 // struct.get
+	StructGetS       = 0x03 // struct.get_s
+	StructGetU       = 0x04 // struct.get_u
+	StructSet        = 0x05 // struct.set
+	ArrayNew         = 0x06 // array.new
+	ArrayNewDefault  = 0x07 // array.new_default
+	ArrayNewFixed    = 0x08 // array.new_fixed
+	ArrayNewData     = 0x09 // array.new_data
+	ArrayNewElem     = 0x0A // array.new_elem
+	ArrayGet         = 0x0B // array.get
+	ArrayGetS        = 0x0C // array.get_s
+	ArrayGetU        = 0x0D // array.get_u
+	ArraySet         = 0x0E // array.set
+	ArrayLen         = 0x0F // array.len
+	ArrayFill        = 0x10 // array.fill
+	ArrayCopy        = 0x11 // array.copy
+	ArrayInitData    = 0x12 // array.init_data
+	ArrayInitElem    = 0x13 // array.init_elem
+	RefTest          = 0x14 // ref.test
+	RefTestNull      = 0x15 // ref.test null
+	RefCast          = 0x16 // ref.cast
+	RefCastNull      = 0x17 // ref.cast null
+	BrOnCast         = 0x18 // br_on_cast
+	BrOnCastFail     = 0x19 // br_on_cast_fail
+	AnyConvertExtern = 0x1A // any.convert_extern
+	ExternConvertAny = 0x1B // extern.convert_any
+	RefI31           = 0x1C // ref.i31
+	I31GetS          = 0x1D // i31.get_s
+	I31GetU          = 0x1E // i31.get_u
+)
+
+var namesFB = map[uint32]string{
+	StructNew:        "struct.new",
+	StructNewDefault: "struct.new_default",
+	StructGet:        "struct.get",
+	StructGetS:       "struct.get_s",
+	StructGetU:       "struct.get_u",
+	StructSet:        "struct.set",
+	ArrayNew:         "array.new",
+	ArrayNewDefault:  "array.new_default",
+	ArrayNewFixed:    "array.new_fixed",
+	ArrayNewData:     "array.new_data",
+	ArrayNewElem:     "array.new_elem",
+	ArrayGet:         "array.get",
+	ArrayGetS:        "array.get_s",
+	ArrayGetU:        "array.get_u",
+	ArraySet:         "array.set",
+	ArrayLen:         "array.len",
+	ArrayFill:        "array.fill",
+	ArrayCopy:        "array.copy",
+	ArrayInitData:    "array.init_data",
+	ArrayInitElem:    "array.init_elem",
+	RefTest:          "ref.test",
+	RefTestNull:      "ref.test",
+	RefCast:          "ref.cast",
+	RefCastNull:      "ref.cast",
+	BrOnCast:         "br_on_cast",
+	BrOnCastFail:     "br_on_cast_fail",
+	AnyConvertExtern: "any.convert_extern",
+	ExternConvertAny: "extern.convert_any",
+	RefI31:           "ref.i31",
+	I31GetS:          "i31.get_s",
+	I31GetU:          "i31.get_u",
+}
+
+// NameFB returns the mnemonic of a sub-opcode following PrefixFB.
+func NameFB(sub uint32) string {
+	if name, ok := namesFB[sub]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown 0xfb %d>", sub)
+}
+
+// IsValidFB reports whether sub is a known sub-opcode following PrefixFB.
+func IsValidFB(sub uint32) bool {
+	_, ok := namesFB[sub]
+	return ok
+}
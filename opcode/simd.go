@@ -0,0 +1,500 @@
+package opcode
+
+import "fmt"
+
+// PrefixFD is the prefix of the fixed-width SIMD instructions, which follow
+// it with a u32 sub-opcode.
+const PrefixFD = 0xFD
+
+// Sub-opcodes following PrefixFD.
+const (
+	V128Load                  = 0x00 // v128.load
+	V128Load8x8S              = 0x01 // v128.load8x8_s
+	V128Load8x8U              = 0x02 // v128.load8x8_u
+	V128Load16x4S             = 0x03 // v128.load16x4_s
+	V128Load16x4U             = 0x04 // v128.load16x4_u
+	V128Load32x2S             = 0x05 // v128.load32x2_s
+	V128Load32x2U             = 0x06 // v128.load32x2_u
+	V128Load8Splat            = 0x07 // v128.load8_splat
+	V128Load16Splat           = 0x08 // v128.load16_splat
+	V128Load32Splat           = 0x09 // v128.load32_splat
+	V128Load64Splat           = 0x0A // v128.load64_splat
+	V128Store                 = 0x0B // v128.store
+	V128Const                 = 0x0C // v128.const
+	I8x16Shuffle              = 0x0D // i8x16.shuffle
+	I8x16Swizzle              = 0x0E // i8x16.swizzle
+	I8x16Splat                = 0x0F // i8x16.splat
+	I16x8Splat                = 0x10 // i16x8.splat
+	I32x4Splat                = 0x11 // i32x4.splat
+	I64x2Splat                = 0x12 // i64x2.splat
+	F32x4Splat                = 0x13 // f32x4.splat
+	F64x2Splat                = 0x14 // f64x2.splat
+	I8x16ExtractLaneS         = 0x15 // i8x16.extract_lane_s
+	I8x16ExtractLaneU         = 0x16 // i8x16.extract_lane_u
+	I8x16ReplaceLane          = 0x17 // i8x16.replace_lane
+	I16x8ExtractLaneS         = 0x18 // i16x8.extract_lane_s
+	I16x8ExtractLaneU         = 0x19 // i16x8.extract_lane_u
+	I16x8ReplaceLane          = 0x1A // i16x8.replace_lane
+	I32x4ExtractLane          = 0x1B // i32x4.extract_lane
+	I32x4ReplaceLane          = 0x1C // i32x4.replace_lane
+	I64x2ExtractLane          = 0x1D // i64x2.extract_lane
+	I64x2ReplaceLane          = 0x1E // i64x2.replace_lane
+	F32x4ExtractLane          = 0x1F // f32x4.extract_lane
+	F32x4ReplaceLane          = 0x20 // f32x4.replace_lane
+	F64x2ExtractLane          = 0x21 // f64x2.extract_lane
+	F64x2ReplaceLane          = 0x22 // f64x2.replace_lane
+	I8x16Eq                   = 0x23 // i8x16.eq
+	I8x16Ne                   = 0x24 // i8x16.ne
+	I8x16LtS                  = 0x25 // i8x16.lt_s
+	I8x16LtU                  = 0x26 // i8x16.lt_u
+	I8x16GtS                  = 0x27 // i8x16.gt_s
+	I8x16GtU                  = 0x28 // i8x16.gt_u
+	I8x16LeS                  = 0x29 // i8x16.le_s
+	I8x16LeU                  = 0x2A // i8x16.le_u
+	I8x16GeS                  = 0x2B // i8x16.ge_s
+	I8x16GeU                  = 0x2C // i8x16.ge_u
+	I16x8Eq                   = 0x2D // i16x8.eq
+	I16x8Ne                   = 0x2E // i16x8.ne
+	I16x8LtS                  = 0x2F // i16x8.lt_s
+	I16x8LtU                  = 0x30 // i16x8.lt_u
+	I16x8GtS                  = 0x31 // i16x8.gt_s
+	I16x8GtU                  = 0x32 // i16x8.gt_u
+	I16x8LeS                  = 0x33 // i16x8.le_s
+	I16x8LeU                  = 0x34 // i16x8.le_u
+	I16x8GeS                  = 0x35 // i16x8.ge_s
+	I16x8GeU                  = 0x36 // i16x8.ge_u
+	I32x4Eq                   = 0x37 // i32x4.eq
+	I32x4Ne                   = 0x38 // i32x4.ne
+	I32x4LtS                  = 0x39 // i32x4.lt_s
+	I32x4LtU                  = 0x3A // i32x4.lt_u
+	I32x4GtS                  = 0x3B // i32x4.gt_s
+	I32x4GtU                  = 0x3C // i32x4.gt_u
+	I32x4LeS                  = 0x3D // i32x4.le_s
+	I32x4LeU                  = 0x3E // i32x4.le_u
+	I32x4GeS                  = 0x3F // i32x4.ge_s
+	I32x4GeU                  = 0x40 // i32x4.ge_u
+	F32x4Eq                   = 0x41 // f32x4.eq
+	F32x4Ne                   = 0x42 // f32x4.ne
+	F32x4Lt                   = 0x43 // f32x4.lt
+	F32x4Gt                   = 0x44 // f32x4.gt
+	F32x4Le                   = 0x45 // f32x4.le
+	F32x4Ge                   = 0x46 // f32x4.ge
+	F64x2Eq                   = 0x47 // f64x2.eq
+	F64x2Ne                   = 0x48 // f64x2.ne
+	F64x2Lt                   = 0x49 // f64x2.lt
+	F64x2Gt                   = 0x4A // f64x2.gt
+	F64x2Le                   = 0x4B // f64x2.le
+	F64x2Ge                   = 0x4C // f64x2.ge
+	V128Not                   = 0x4D // v128.not
+	V128And                   = 0x4E // v128.and
+	V128Andnot                = 0x4F // v128.andnot
+	V128Or                    = 0x50 // v128.or
+	V128Xor                   = 0x51 // v128.xor
+	V128Bitselect             = 0x52 // v128.bitselect
+	V128AnyTrue               = 0x53 // v128.any_true
+	V128Load8Lane             = 0x54 // v128.load8_lane
+	V128Load16Lane            = 0x55 // v128.load16_lane
+	V128Load32Lane            = 0x56 // v128.load32_lane
+	V128Load64Lane            = 0x57 // v128.load64_lane
+	V128Store8Lane            = 0x58 // v128.store8_lane
+	V128Store16Lane           = 0x59 // v128.store16_lane
+	V128Store32Lane           = 0x5A // v128.store32_lane
+	V128Store64Lane           = 0x5B // v128.store64_lane
+	V128Load32Zero            = 0x5C // v128.load32_zero
+	V128Load64Zero            = 0x5D // v128.load64_zero
+	F32x4DemoteF64x2Zero      = 0x5E // f32x4.demote_f64x2_zero
+	F64x2PromoteLowF32x4      = 0x5F // f64x2.promote_low_f32x4
+	I8x16Abs                  = 0x60 // i8x16.abs
+	I8x16Neg                  = 0x61 // i8x16.neg
+	I8x16Popcnt               = 0x62 // i8x16.popcnt
+	I8x16AllTrue              = 0x63 // i8x16.all_true
+	I8x16Bitmask              = 0x64 // i8x16.bitmask
+	I8x16NarrowI16x8S         = 0x65 // i8x16.narrow_i16x8_s
+	I8x16NarrowI16x8U         = 0x66 // i8x16.narrow_i16x8_u
+	F32x4Ceil                 = 0x67 // f32x4.ceil
+	F32x4Floor                = 0x68 // f32x4.floor
+	F32x4Trunc                = 0x69 // f32x4.trunc
+	F32x4Nearest              = 0x6A // f32x4.nearest
+	I8x16Shl                  = 0x6B // i8x16.shl
+	I8x16ShrS                 = 0x6C // i8x16.shr_s
+	I8x16ShrU                 = 0x6D // i8x16.shr_u
+	I8x16Add                  = 0x6E // i8x16.add
+	I8x16AddSatS              = 0x6F // i8x16.add_sat_s
+	I8x16AddSatU              = 0x70 // i8x16.add_sat_u
+	I8x16Sub                  = 0x71 // i8x16.sub
+	I8x16SubSatS              = 0x72 // i8x16.sub_sat_s
+	I8x16SubSatU              = 0x73 // i8x16.sub_sat_u
+	F64x2Ceil                 = 0x74 // f64x2.ceil
+	F64x2Floor                = 0x75 // f64x2.floor
+	I8x16MinS                 = 0x76 // i8x16.min_s
+	I8x16MinU                 = 0x77 // i8x16.min_u
+	I8x16MaxS                 = 0x78 // i8x16.max_s
+	I8x16MaxU                 = 0x79 // i8x16.max_u
+	F64x2Trunc                = 0x7A // f64x2.trunc
+	I8x16AvgrU                = 0x7B // i8x16.avgr_u
+	I16x8ExtaddPairwiseI8x16S = 0x7C // i16x8.extadd_pairwise_i8x16_s
+	I16x8ExtaddPairwiseI8x16U = 0x7D // i16x8.extadd_pairwise_i8x16_u
+	I32x4ExtaddPairwiseI16x8S = 0x7E // i32x4.extadd_pairwise_i16x8_s
+	I32x4ExtaddPairwiseI16x8U = 0x7F // i32x4.extadd_pairwise_i16x8_u
+	I16x8Abs                  = 0x80 // i16x8.abs
+	I16x8Neg                  = 0x81 // i16x8.neg
+	I16x8Q15mulrSatS          = 0x82 // i16x8.q15mulr_sat_s
+	I16x8AllTrue              = 0x83 // i16x8.all_true
+	I16x8Bitmask              = 0x84 // i16x8.bitmask
+	I16x8NarrowI32x4S         = 0x85 // i16x8.narrow_i32x4_s
+	I16x8NarrowI32x4U         = 0x86 // i16x8.narrow_i32x4_u
+	I16x8ExtendLowI8x16S      = 0x87 // i16x8.extend_low_i8x16_s
+	I16x8ExtendHighI8x16S     = 0x88 // i16x8.extend_high_i8x16_s
+	I16x8ExtendLowI8x16U      = 0x89 // i16x8.extend_low_i8x16_u
+	I16x8ExtendHighI8x16U     = 0x8A // i16x8.extend_high_i8x16_u
+	I16x8Shl                  = 0x8B // i16x8.shl
+	I16x8ShrS                 = 0x8C // i16x8.shr_s
+	I16x8ShrU                 = 0x8D // i16x8.shr_u
+	I16x8Add                  = 0x8E // i16x8.add
+	I16x8AddSatS              = 0x8F // i16x8.add_sat_s
+	I16x8AddSatU              = 0x90 // i16x8.add_sat_u
+	I16x8Sub                  = 0x91 // i16x8.sub
+	I16x8SubSatS              = 0x92 // i16x8.sub_sat_s
+	I16x8SubSatU              = 0x93 // i16x8.sub_sat_u
+	F64x2Nearest              = 0x94 // f64x2.nearest
+	I16x8Mul                  = 0x95 // i16x8.mul
+	I16x8MinS                 = 0x96 // i16x8.min_s
+	I16x8MinU                 = 0x97 // i16x8.min_u
+	I16x8MaxS                 = 0x98 // i16x8.max_s
+	I16x8MaxU                 = 0x99 // i16x8.max_u
+	I16x8AvgrU                = 0x9B // i16x8.avgr_u
+	I16x8ExtmulLowI8x16S      = 0x9C // i16x8.extmul_low_i8x16_s
+	I16x8ExtmulHighI8x16S     = 0x9D // i16x8.extmul_high_i8x16_s
+	I16x8ExtmulLowI8x16U      = 0x9E // i16x8.extmul_low_i8x16_u
+	I16x8ExtmulHighI8x16U     = 0x9F // i16x8.extmul_high_i8x16_u
+	I32x4Abs                  = 0xA0 // i32x4.abs
+	I32x4Neg                  = 0xA1 // i32x4.neg
+	I32x4AllTrue              = 0xA3 // i32x4.all_true
+	I32x4Bitmask              = 0xA4 // i32x4.bitmask
+	I32x4ExtendLowI16x8S      = 0xA7 // i32x4.extend_low_i16x8_s
+	I32x4ExtendHighI16x8S     = 0xA8 // i32x4.extend_high_i16x8_s
+	I32x4ExtendLowI16x8U      = 0xA9 // i32x4.extend_low_i16x8_u
+	I32x4ExtendHighI16x8U     = 0xAA // i32x4.extend_high_i16x8_u
+	I32x4Shl                  = 0xAB // i32x4.shl
+	I32x4ShrS                 = 0xAC // i32x4.shr_s
+	I32x4ShrU                 = 0xAD // i32x4.shr_u
+	I32x4Add                  = 0xAE // i32x4.add
+	I32x4Sub                  = 0xB1 // i32x4.sub
+	I32x4Mul                  = 0xB5 // i32x4.mul
+	I32x4MinS                 = 0xB6 // i32x4.min_s
+	I32x4MinU                 = 0xB7 // i32x4.min_u
+	I32x4MaxS                 = 0xB8 // i32x4.max_s
+	I32x4MaxU                 = 0xB9 // i32x4.max_u
+	I32x4DotI16x8S            = 0xBA // i32x4.dot_i16x8_s
+	I32x4ExtmulLowI16x8S      = 0xBC // i32x4.extmul_low_i16x8_s
+	I32x4ExtmulHighI16x8S     = 0xBD // i32x4.extmul_high_i16x8_s
+	I32x4ExtmulLowI16x8U      = 0xBE // i32x4.extmul_low_i16x8_u
+	I32x4ExtmulHighI16x8U     = 0xBF // i32x4.extmul_high_i16x8_u
+	I64x2Abs                  = 0xC0 // i64x2.abs
+	I64x2Neg                  = 0xC1 // i64x2.neg
+	I64x2AllTrue              = 0xC3 // i64x2.all_true
+	I64x2Bitmask              = 0xC4 // i64x2.bitmask
+	I64x2ExtendLowI32x4S      = 0xC7 // i64x2.extend_low_i32x4_s
+	I64x2ExtendHighI32x4S     = 0xC8 // i64x2.extend_high_i32x4_s
+	I64x2ExtendLowI32x4U      = 0xC9 // i64x2.extend_low_i32x4_u
+	I64x2ExtendHighI32x4U     = 0xCA // i64x2.extend_high_i32x4_u
+	I64x2Shl                  = 0xCB // i64x2.shl
+	I64x2ShrS                 = 0xCC // i64x2.shr_s
+	I64x2ShrU                 = 0xCD // i64x2.shr_u
+	I64x2Add                  = 0xCE // i64x2.add
+	I64x2Sub                  = 0xD1 // i64x2.sub
+	I64x2Mul                  = 0xD5 // i64x2.mul
+	I64x2Eq                   = 0xD6 // i64x2.eq
+	I64x2Ne                   = 0xD7 // i64x2.ne
+	I64x2LtS                  = 0xD8 // i64x2.lt_s
+	I64x2GtS                  = 0xD9 // i64x2.gt_s
+	I64x2LeS                  = 0xDA // i64x2.le_s
+	I64x2GeS                  = 0xDB // i64x2.ge_s
+	I64x2ExtmulLowI32x4S      = 0xDC // i64x2.extmul_low_i32x4_s
+	I64x2ExtmulHighI32x4S     = 0xDD // i64x2.extmul_high_i32x4_s
+	I64x2ExtmulLowI32x4U      = 0xDE // i64x2.extmul_low_i32x4_u
+	I64x2ExtmulHighI32x4U     = 0xDF // i64x2.extmul_high_i32x4_u
+	F32x4Abs                  = 0xE0 // f32x4.abs
+	F32x4Neg                  = 0xE1 // f32x4.neg
+	F32x4Sqrt                 = 0xE3 // f32x4.sqrt
+	F32x4Add                  = 0xE4 // f32x4.add
+	F32x4Sub                  = 0xE5 // f32x4.sub
+	F32x4Mul                  = 0xE6 // f32x4.mul
+	F32x4Div                  = 0xE7 // f32x4.div
+	F32x4Min                  = 0xE8 // f32x4.min
+	F32x4Max                  = 0xE9 // f32x4.max
+	F32x4Pmin                 = 0xEA // f32x4.pmin
+	F32x4Pmax                 = 0xEB // f32x4.pmax
+	F64x2Abs                  = 0xEC // f64x2.abs
+	F64x2Neg                  = 0xED // f64x2.neg
+	F64x2Sqrt                 = 0xEF // f64x2.sqrt
+	F64x2Add                  = 0xF0 // f64x2.add
+	F64x2Sub                  = 0xF1 // f64x2.sub
+	F64x2Mul                  = 0xF2 // f64x2.mul
+	F64x2Div                  = 0xF3 // f64x2.div
+	F64x2Min                  = 0xF4 // f64x2.min
+	F64x2Max                  = 0xF5 // f64x2.max
+	F64x2Pmin                 = 0xF6 // f64x2.pmin
+	F64x2Pmax                 = 0xF7 // f64x2.pmax
+	I32x4TruncSatF32x4S       = 0xF8 // i32x4.trunc_sat_f32x4_s
+	I32x4TruncSatF32x4U       = 0xF9 // i32x4.trunc_sat_f32x4_u
+	F32x4ConvertI32x4S        = 0xFA // f32x4.convert_i32x4_s
+	F32x4ConvertI32x4U        = 0xFB // f32x4.convert_i32x4_u
+	I32x4TruncSatF64x2SZero   = 0xFC // i32x4.trunc_sat_f64x2_s_zero
+	I32x4TruncSatF64x2UZero   = 0xFD // i32x4.trunc_sat_f64x2_u_zero
+	F64x2ConvertLowI32x4S     = 0xFE // f64x2.convert_low_i32x4_s
+	F64x2ConvertLowI32x4U     = 0xFF // f64x2.convert_low_i32x4_u
+)
+
+var namesFD = map[uint32]string{
+	V128Load:                  "v128.load",
+	V128Load8x8S:              "v128.load8x8_s",
+	V128Load8x8U:              "v128.load8x8_u",
+	V128Load16x4S:             "v128.load16x4_s",
+	V128Load16x4U:             "v128.load16x4_u",
+	V128Load32x2S:             "v128.load32x2_s",
+	V128Load32x2U:             "v128.load32x2_u",
+	V128Load8Splat:            "v128.load8_splat",
+	V128Load16Splat:           "v128.load16_splat",
+	V128Load32Splat:           "v128.load32_splat",
+	V128Load64Splat:           "v128.load64_splat",
+	V128Store:                 "v128.store",
+	V128Const:                 "v128.const",
+	I8x16Shuffle:              "i8x16.shuffle",
+	I8x16Swizzle:              "i8x16.swizzle",
+	I8x16Splat:                "i8x16.splat",
+	I16x8Splat:                "i16x8.splat",
+	I32x4Splat:                "i32x4.splat",
+	I64x2Splat:                "i64x2.splat",
+	F32x4Splat:                "f32x4.splat",
+	F64x2Splat:                "f64x2.splat",
+	I8x16ExtractLaneS:         "i8x16.extract_lane_s",
+	I8x16ExtractLaneU:         "i8x16.extract_lane_u",
+	I8x16ReplaceLane:          "i8x16.replace_lane",
+	I16x8ExtractLaneS:         "i16x8.extract_lane_s",
+	I16x8ExtractLaneU:         "i16x8.extract_lane_u",
+	I16x8ReplaceLane:          "i16x8.replace_lane",
+	I32x4ExtractLane:          "i32x4.extract_lane",
+	I32x4ReplaceLane:          "i32x4.replace_lane",
+	I64x2ExtractLane:          "i64x2.extract_lane",
+	I64x2ReplaceLane:          "i64x2.replace_lane",
+	F32x4ExtractLane:          "f32x4.extract_lane",
+	F32x4ReplaceLane:          "f32x4.replace_lane",
+	F64x2ExtractLane:          "f64x2.extract_lane",
+	F64x2ReplaceLane:          "f64x2.replace_lane",
+	I8x16Eq:                   "i8x16.eq",
+	I8x16Ne:                   "i8x16.ne",
+	I8x16LtS:                  "i8x16.lt_s",
+	I8x16LtU:                  "i8x16.lt_u",
+	I8x16GtS:                  "i8x16.gt_s",
+	I8x16GtU:                  "i8x16.gt_u",
+	I8x16LeS:                  "i8x16.le_s",
+	I8x16LeU:                  "i8x16.le_u",
+	I8x16GeS:                  "i8x16.ge_s",
+	I8x16GeU:                  "i8x16.ge_u",
+	I16x8Eq:                   "i16x8.eq",
+	I16x8Ne:                   "i16x8.ne",
+	I16x8LtS:                  "i16x8.lt_s",
+	I16x8LtU:                  "i16x8.lt_u",
+	I16x8GtS:                  "i16x8.gt_s",
+	I16x8GtU:                  "i16x8.gt_u",
+	I16x8LeS:                  "i16x8.le_s",
+	I16x8LeU:                  "i16x8.le_u",
+	I16x8GeS:                  "i16x8.ge_s",
+	I16x8GeU:                  "i16x8.ge_u",
+	I32x4Eq:                   "i32x4.eq",
+	I32x4Ne:                   "i32x4.ne",
+	I32x4LtS:                  "i32x4.lt_s",
+	I32x4LtU:                  "i32x4.lt_u",
+	I32x4GtS:                  "i32x4.gt_s",
+	I32x4GtU:                  "i32x4.gt_u",
+	I32x4LeS:                  "i32x4.le_s",
+	I32x4LeU:                  "i32x4.le_u",
+	I32x4GeS:                  "i32x4.ge_s",
+	I32x4GeU:                  "i32x4.ge_u",
+	F32x4Eq:                   "f32x4.eq",
+	F32x4Ne:                   "f32x4.ne",
+	F32x4Lt:                   "f32x4.lt",
+	F32x4Gt:                   "f32x4.gt",
+	F32x4Le:                   "f32x4.le",
+	F32x4Ge:                   "f32x4.ge",
+	F64x2Eq:                   "f64x2.eq",
+	F64x2Ne:                   "f64x2.ne",
+	F64x2Lt:                   "f64x2.lt",
+	F64x2Gt:                   "f64x2.gt",
+	F64x2Le:                   "f64x2.le",
+	F64x2Ge:                   "f64x2.ge",
+	V128Not:                   "v128.not",
+	V128And:                   "v128.and",
+	V128Andnot:                "v128.andnot",
+	V128Or:                    "v128.or",
+	V128Xor:                   "v128.xor",
+	V128Bitselect:             "v128.bitselect",
+	V128AnyTrue:               "v128.any_true",
+	V128Load8Lane:             "v128.load8_lane",
+	V128Load16Lane:            "v128.load16_lane",
+	V128Load32Lane:            "v128.load32_lane",
+	V128Load64Lane:            "v128.load64_lane",
+	V128Store8Lane:            "v128.store8_lane",
+	V128Store16Lane:           "v128.store16_lane",
+	V128Store32Lane:           "v128.store32_lane",
+	V128Store64Lane:           "v128.store64_lane",
+	V128Load32Zero:            "v128.load32_zero",
+	V128Load64Zero:            "v128.load64_zero",
+	F32x4DemoteF64x2Zero:      "f32x4.demote_f64x2_zero",
+	F64x2PromoteLowF32x4:      "f64x2.promote_low_f32x4",
+	I8x16Abs:                  "i8x16.abs",
+	I8x16Neg:                  "i8x16.neg",
+	I8x16Popcnt:               "i8x16.popcnt",
+	I8x16AllTrue:              "i8x16.all_true",
+	I8x16Bitmask:              "i8x16.bitmask",
+	I8x16NarrowI16x8S:         "i8x16.narrow_i16x8_s",
+	I8x16NarrowI16x8U:         "i8x16.narrow_i16x8_u",
+	F32x4Ceil:                 "f32x4.ceil",
+	F32x4Floor:                "f32x4.floor",
+	F32x4Trunc:                "f32x4.trunc",
+	F32x4Nearest:              "f32x4.nearest",
+	I8x16Shl:                  "i8x16.shl",
+	I8x16ShrS:                 "i8x16.shr_s",
+	I8x16ShrU:                 "i8x16.shr_u",
+	I8x16Add:                  "i8x16.add",
+	I8x16AddSatS:              "i8x16.add_sat_s",
+	I8x16AddSatU:              "i8x16.add_sat_u",
+	I8x16Sub:                  "i8x16.sub",
+	I8x16SubSatS:              "i8x16.sub_sat_s",
+	I8x16SubSatU:              "i8x16.sub_sat_u",
+	F64x2Ceil:                 "f64x2.ceil",
+	F64x2Floor:                "f64x2.floor",
+	I8x16MinS:                 "i8x16.min_s",
+	I8x16MinU:                 "i8x16.min_u",
+	I8x16MaxS:                 "i8x16.max_s",
+	I8x16MaxU:                 "i8x16.max_u",
+	F64x2Trunc:                "f64x2.trunc",
+	I8x16AvgrU:                "i8x16.avgr_u",
+	I16x8ExtaddPairwiseI8x16S: "i16x8.extadd_pairwise_i8x16_s",
+	I16x8ExtaddPairwiseI8x16U: "i16x8.extadd_pairwise_i8x16_u",
+	I32x4ExtaddPairwiseI16x8S: "i32x4.extadd_pairwise_i16x8_s",
+	I32x4ExtaddPairwiseI16x8U: "i32x4.extadd_pairwise_i16x8_u",
+	I16x8Abs:                  "i16x8.abs",
+	I16x8Neg:                  "i16x8.neg",
+	I16x8Q15mulrSatS:          "i16x8.q15mulr_sat_s",
+	I16x8AllTrue:              "i16x8.all_true",
+	I16x8Bitmask:              "i16x8.bitmask",
+	I16x8NarrowI32x4S:         "i16x8.narrow_i32x4_s",
+	I16x8NarrowI32x4U:         "i16x8.narrow_i32x4_u",
+	I16x8ExtendLowI8x16S:      "i16x8.extend_low_i8x16_s",
+	I16x8ExtendHighI8x16S:     "i16x8.extend_high_i8x16_s",
+	I16x8ExtendLowI8x16U:      "i16x8.extend_low_i8x16_u",
+	I16x8ExtendHighI8x16U:     "i16x8.extend_high_i8x16_u",
+	I16x8Shl:                  "i16x8.shl",
+	I16x8ShrS:                 "i16x8.shr_s",
+	I16x8ShrU:                 "i16x8.shr_u",
+	I16x8Add:                  "i16x8.add",
+	I16x8AddSatS:              "i16x8.add_sat_s",
+	I16x8AddSatU:              "i16x8.add_sat_u",
+	I16x8Sub:                  "i16x8.sub",
+	I16x8SubSatS:              "i16x8.sub_sat_s",
+	I16x8SubSatU:              "i16x8.sub_sat_u",
+	F64x2Nearest:              "f64x2.nearest",
+	I16x8Mul:                  "i16x8.mul",
+	I16x8MinS:                 "i16x8.min_s",
+	I16x8MinU:                 "i16x8.min_u",
+	I16x8MaxS:                 "i16x8.max_s",
+	I16x8MaxU:                 "i16x8.max_u",
+	I16x8AvgrU:                "i16x8.avgr_u",
+	I16x8ExtmulLowI8x16S:      "i16x8.extmul_low_i8x16_s",
+	I16x8ExtmulHighI8x16S:     "i16x8.extmul_high_i8x16_s",
+	I16x8ExtmulLowI8x16U:      "i16x8.extmul_low_i8x16_u",
+	I16x8ExtmulHighI8x16U:     "i16x8.extmul_high_i8x16_u",
+	I32x4Abs:                  "i32x4.abs",
+	I32x4Neg:                  "i32x4.neg",
+	I32x4AllTrue:              "i32x4.all_true",
+	I32x4Bitmask:              "i32x4.bitmask",
+	I32x4ExtendLowI16x8S:      "i32x4.extend_low_i16x8_s",
+	I32x4ExtendHighI16x8S:     "i32x4.extend_high_i16x8_s",
+	I32x4ExtendLowI16x8U:      "i32x4.extend_low_i16x8_u",
+	I32x4ExtendHighI16x8U:     "i32x4.extend_high_i16x8_u",
+	I32x4Shl:                  "i32x4.shl",
+	I32x4ShrS:                 "i32x4.shr_s",
+	I32x4ShrU:                 "i32x4.shr_u",
+	I32x4Add:                  "i32x4.add",
+	I32x4Sub:                  "i32x4.sub",
+	I32x4Mul:                  "i32x4.mul",
+	I32x4MinS:                 "i32x4.min_s",
+	I32x4MinU:                 "i32x4.min_u",
+	I32x4MaxS:                 "i32x4.max_s",
+	I32x4MaxU:                 "i32x4.max_u",
+	I32x4DotI16x8S:            "i32x4.dot_i16x8_s",
+	I32x4ExtmulLowI16x8S:      "i32x4.extmul_low_i16x8_s",
+	I32x4ExtmulHighI16x8S:     "i32x4.extmul_high_i16x8_s",
+	I32x4ExtmulLowI16x8U:      "i32x4.extmul_low_i16x8_u",
+	I32x4ExtmulHighI16x8U:     "i32x4.extmul_high_i16x8_u",
+	I64x2Abs:                  "i64x2.abs",
+	I64x2Neg:                  "i64x2.neg",
+	I64x2AllTrue:              "i64x2.all_true",
+	I64x2Bitmask:              "i64x2.bitmask",
+	I64x2ExtendLowI32x4S:      "i64x2.extend_low_i32x4_s",
+	I64x2ExtendHighI32x4S:     "i64x2.extend_high_i32x4_s",
+	I64x2ExtendLowI32x4U:      "i64x2.extend_low_i32x4_u",
+	I64x2ExtendHighI32x4U:     "i64x2.extend_high_i32x4_u",
+	I64x2Shl:                  "i64x2.shl",
+	I64x2ShrS:                 "i64x2.shr_s",
+	I64x2ShrU:                 "i64x2.shr_u",
+	I64x2Add:                  "i64x2.add",
+	I64x2Sub:                  "i64x2.sub",
+	I64x2Mul:                  "i64x2.mul",
+	I64x2Eq:                   "i64x2.eq",
+	I64x2Ne:                   "i64x2.ne",
+	I64x2LtS:                  "i64x2.lt_s",
+	I64x2GtS:                  "i64x2.gt_s",
+	I64x2LeS:                  "i64x2.le_s",
+	I64x2GeS:                  "i64x2.ge_s",
+	I64x2ExtmulLowI32x4S:      "i64x2.extmul_low_i32x4_s",
+	I64x2ExtmulHighI32x4S:     "i64x2.extmul_high_i32x4_s",
+	I64x2ExtmulLowI32x4U:      "i64x2.extmul_low_i32x4_u",
+	I64x2ExtmulHighI32x4U:     "i64x2.extmul_high_i32x4_u",
+	F32x4Abs:                  "f32x4.abs",
+	F32x4Neg:                  "f32x4.neg",
+	F32x4Sqrt:                 "f32x4.sqrt",
+	F32x4Add:                  "f32x4.add",
+	F32x4Sub:                  "f32x4.sub",
+	F32x4Mul:                  "f32x4.mul",
+	F32x4Div:                  "f32x4.div",
+	F32x4Min:                  "f32x4.min",
+	F32x4Max:                  "f32x4.max",
+	F32x4Pmin:                 "f32x4.pmin",
+	F32x4Pmax:                 "f32x4.pmax",
+	F64x2Abs:                  "f64x2.abs",
+	F64x2Neg:                  "f64x2.neg",
+	F64x2Sqrt:                 "f64x2.sqrt",
+	F64x2Add:                  "f64x2.add",
+	F64x2Sub:                  "f64x2.sub",
+	F64x2Mul:                  "f64x2.mul",
+	F64x2Div:                  "f64x2.div",
+	F64x2Min:                  "f64x2.min",
+	F64x2Max:                  "f64x2.max",
+	F64x2Pmin:                 "f64x2.pmin",
+	F64x2Pmax:                 "f64x2.pmax",
+	I32x4TruncSatF32x4S:       "i32x4.trunc_sat_f32x4_s",
+	I32x4TruncSatF32x4U:       "i32x4.trunc_sat_f32x4_u",
+	F32x4ConvertI32x4S:        "f32x4.convert_i32x4_s",
+	F32x4ConvertI32x4U:        "f32x4.convert_i32x4_u",
+	I32x4TruncSatF64x2SZero:   "i32x4.trunc_sat_f64x2_s_zero",
+	I32x4TruncSatF64x2UZero:   "i32x4.trunc_sat_f64x2_u_zero",
+	F64x2ConvertLowI32x4S:     "f64x2.convert_low_i32x4_s",
+	F64x2ConvertLowI32x4U:     "f64x2.convert_low_i32x4_u",
+}
+
+// NameFD returns the mnemonic of a sub-opcode following PrefixFD.
+func NameFD(sub uint32) string {
+	if name, ok := namesFD[sub]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown 0xfd %d>", sub)
+}
+
+// IsValidFD reports whether sub is a known sub-opcode following PrefixFD.
+func IsValidFD(sub uint32) bool {
+	_, ok := namesFD[sub]
+	return ok
+}
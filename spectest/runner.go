@@ -0,0 +1,335 @@
+package spectest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/luyiming112233/wasm/decode"
+)
+
+// Options configures a script run.
+type Options struct {
+	// AllowSkips counts commands that need a stage this project does not
+	// have yet (text format parsing, validation, execution) as skipped.
+	// Otherwise they are failures, so that a run only passes if every
+	// command was actually checked.
+	AllowSkips bool
+	// SkipLines lists the commands, by the line they start on, that may be
+	// skipped without AllowSkips.
+	SkipLines []int
+}
+
+// Result summarizes a script run.
+type Result struct {
+	Passed   int
+	Failed   int
+	Skipped  int
+	Failures []string
+}
+
+func (r *Result) pass() {
+	r.Passed++
+}
+
+func (r *Result) fail(cmd *SExpr, format string, args ...any) {
+	r.Failed++
+	r.Failures = append(r.Failures, fmt.Sprintf("line %d: %s: %s", cmd.Line, cmd.Head(), fmt.Sprintf(format, args...)))
+}
+
+// Runner executes the commands of one script.
+type Runner struct {
+	opts       Options
+	modules    map[string]*decode.Module
+	registered map[string]*decode.Module
+	current    *decode.Module
+	result     Result
+}
+
+func NewRunner(opts Options) *Runner {
+	return &Runner{
+		opts:       opts,
+		modules:    map[string]*decode.Module{},
+		registered: map[string]*decode.Module{},
+	}
+}
+
+// skip records a command that could not be checked, see Options.AllowSkips
+// and Options.SkipLines.
+func (r *Runner) skip(cmd *SExpr, reason string) {
+	if !r.opts.AllowSkips && !slices.Contains(r.opts.SkipLines, cmd.Line) {
+		r.result.fail(cmd, "not checked: %s", reason)
+		return
+	}
+	r.result.Skipped++
+}
+
+// RunFile runs the script at path.
+func RunFile(path string, opts Options) (*Result, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Run(src, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return res, nil
+}
+
+// Run runs a script held in memory.
+func Run(src []byte, opts Options) (*Result, error) {
+	cmds, err := ParseScript(src)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewRunner(opts)
+	for _, cmd := range cmds {
+		if err := r.run(cmd); err != nil {
+			return nil, fmt.Errorf("line %d: %w", cmd.Line, err)
+		}
+	}
+	return &r.result, nil
+}
+
+// run dispatches a top level command. Errors are reserved for scripts the
+// runner cannot make sense of; a wrong answer from the engine is recorded
+// as a failure instead.
+func (r *Runner) run(cmd *SExpr) error {
+	switch cmd.Head() {
+	case "module":
+		return r.runModule(cmd)
+	case "register":
+		return r.runRegister(cmd)
+	case "invoke", "get":
+		_, err := parseAction(cmd)
+		if err != nil {
+			return err
+		}
+		r.skip(cmd, noInterpreter)
+	case "assert_return":
+		return r.runAssertReturn(cmd)
+	case "assert_trap", "assert_exhaustion":
+		return r.runAssertTrap(cmd)
+	case "assert_malformed":
+		return r.runAssertMalformed(cmd)
+	case "assert_invalid":
+		return r.runAssertInvalid(cmd)
+	case "assert_unlinkable", "assert_uninstantiable":
+		r.skip(cmd, noInterpreter)
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Head())
+	}
+	return nil
+}
+
+// Reasons for skipping a command.
+const (
+	noInterpreter = "there is no interpreter"
+	noTextFormat  = "there is no text format parser"
+	noValidator   = "there is no validator"
+)
+
+type moduleKind int
+
+const (
+	moduleText moduleKind = iota
+	moduleBinary
+	moduleQuote
+)
+
+// splitModule returns a module's optional $name, its kind and, for binary
+// modules, the concatenated bytes.
+func splitModule(cmd *SExpr) (name string, kind moduleKind, bin []byte, err error) {
+	if cmd.Head() != "module" {
+		return "", 0, nil, fmt.Errorf("expected module, got %s", cmd)
+	}
+	rest := cmd.List[1:]
+	if len(rest) > 0 && !rest[0].IsList && strings.HasPrefix(rest[0].Atom, "$") {
+		name = rest[0].Atom
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || rest[0].IsList || rest[0].Quoted {
+		return name, moduleText, nil, nil
+	}
+
+	switch rest[0].Atom {
+	case "binary":
+		kind = moduleBinary
+	case "quote":
+		kind = moduleQuote
+	default:
+		return name, moduleText, nil, nil
+	}
+	var sb strings.Builder
+	for _, part := range rest[1:] {
+		if !part.Quoted {
+			return "", 0, nil, fmt.Errorf("expected string in module %s, got %s", rest[0].Atom, part)
+		}
+		sb.WriteString(part.Atom)
+	}
+	return name, kind, []byte(sb.String()), nil
+}
+
+func decodeBinary(bin []byte) (*decode.Module, error) {
+	return decode.DecodeModule(common.NewSliceBytes(bin))
+}
+
+func (r *Runner) runModule(cmd *SExpr) error {
+	name, kind, bin, err := splitModule(cmd)
+	if err != nil {
+		return err
+	}
+	// a module that cannot be loaded makes the commands referring to it
+	// meaningless, so forget the previous one either way
+	r.current = nil
+	if kind != moduleBinary {
+		r.skip(cmd, noTextFormat)
+		return nil
+	}
+
+	module, err := decodeBinary(bin)
+	if err != nil {
+		r.result.fail(cmd, "decode failed: %v", err)
+		return nil
+	}
+	r.result.pass()
+	r.current = module
+	if name != "" {
+		r.modules[name] = module
+	}
+	return nil
+}
+
+func (r *Runner) runRegister(cmd *SExpr) error {
+	if len(cmd.List) < 2 || !cmd.List[1].Quoted {
+		return fmt.Errorf("malformed register %s", cmd)
+	}
+	module := r.current
+	if len(cmd.List) > 2 {
+		module = r.modules[cmd.List[2].Atom]
+	}
+	if module != nil {
+		r.registered[cmd.List[1].Atom] = module
+	}
+	return nil
+}
+
+// action is an `(invoke $mod? "name" arg*)` or `(get $mod? "name")`.
+type action struct {
+	Kind   string
+	Module string
+	Name   string
+	Args   []Value
+}
+
+func parseAction(e *SExpr) (*action, error) {
+	kind := e.Head()
+	if kind != "invoke" && kind != "get" {
+		return nil, fmt.Errorf("expected action, got %s", e)
+	}
+	act := &action{Kind: kind}
+	rest := e.List[1:]
+	if len(rest) > 0 && !rest[0].IsList && !rest[0].Quoted {
+		act.Module = rest[0].Atom
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || !rest[0].Quoted {
+		return nil, fmt.Errorf("malformed action %s", e)
+	}
+	act.Name = rest[0].Atom
+	for _, arg := range rest[1:] {
+		v, err := parseValue(arg, false)
+		if err != nil {
+			return nil, err
+		}
+		act.Args = append(act.Args, v)
+	}
+	return act, nil
+}
+
+func (r *Runner) runAssertReturn(cmd *SExpr) error {
+	if len(cmd.List) < 2 {
+		return fmt.Errorf("malformed %s", cmd)
+	}
+	if _, err := parseAction(cmd.List[1]); err != nil {
+		return err
+	}
+	for _, exp := range cmd.List[2:] {
+		if _, err := parseValue(exp, true); err != nil {
+			return err
+		}
+	}
+	r.skip(cmd, noInterpreter)
+	return nil
+}
+
+func (r *Runner) runAssertTrap(cmd *SExpr) error {
+	if len(cmd.List) != 3 || !cmd.List[2].Quoted {
+		return fmt.Errorf("malformed %s", cmd)
+	}
+	target := cmd.List[1]
+	if target.Head() == "module" {
+		// assert_trap on instantiation (start function)
+		r.skip(cmd, noInterpreter)
+		return nil
+	}
+	if _, err := parseAction(target); err != nil {
+		return err
+	}
+	r.skip(cmd, noInterpreter)
+	return nil
+}
+
+func (r *Runner) runAssertMalformed(cmd *SExpr) error {
+	if len(cmd.List) != 3 || !cmd.List[2].Quoted {
+		return fmt.Errorf("malformed %s", cmd)
+	}
+	_, kind, bin, err := splitModule(cmd.List[1])
+	if err != nil {
+		return err
+	}
+	if kind != moduleBinary {
+		r.skip(cmd, noTextFormat)
+		return nil
+	}
+
+	if _, err := decodeBinary(bin); err == nil {
+		r.result.fail(cmd, "expected %q, module decoded", cmd.List[2].Atom)
+		return nil
+	}
+	r.result.pass()
+	return nil
+}
+
+func (r *Runner) runAssertInvalid(cmd *SExpr) error {
+	if len(cmd.List) != 3 || !cmd.List[2].Quoted {
+		return fmt.Errorf("malformed %s", cmd)
+	}
+	_, kind, bin, err := splitModule(cmd.List[1])
+	if err != nil {
+		return err
+	}
+	if kind != moduleBinary {
+		r.skip(cmd, noTextFormat)
+		return nil
+	}
+
+	// the module must be well-formed and fail validation; the decoder
+	// checks a few validation rules, and there is no validator for the rest
+	_, err = decodeBinary(bin)
+	switch {
+	case errors.Is(err, decode.ErrInvalid):
+		r.result.pass()
+	case err != nil:
+		r.result.fail(cmd, "expected %q, module is malformed: %v", cmd.List[2].Atom, err)
+	default:
+		r.skip(cmd, noValidator)
+	}
+	return nil
+}
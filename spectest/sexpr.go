@@ -0,0 +1,226 @@
+package spectest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SExpr is a node of a .wast script: either a list or an atom. String
+// literals are kept apart from other atoms since `"foo"` and `foo` mean
+// different things in the script format.
+type SExpr struct {
+	List   []*SExpr
+	Atom   string
+	Quoted bool
+	IsList bool
+	Line   int
+}
+
+func (e *SExpr) String() string {
+	if !e.IsList {
+		if e.Quoted {
+			return strconv.Quote(e.Atom)
+		}
+		return e.Atom
+	}
+	parts := make([]string, 0, len(e.List))
+	for _, item := range e.List {
+		parts = append(parts, item.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// Head returns the keyword a list starts with, or "" when there is none.
+func (e *SExpr) Head() string {
+	if !e.IsList || len(e.List) == 0 || e.List[0].IsList || e.List[0].Quoted {
+		return ""
+	}
+	return e.List[0].Atom
+}
+
+// ParseScript splits a .wast script into its top level commands.
+func ParseScript(src []byte) ([]*SExpr, error) {
+	p := &parser{src: src, line: 1}
+	var cmds []*SExpr
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return cmds, nil
+		}
+		expr, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		if !expr.IsList {
+			return nil, fmt.Errorf("line %d: unexpected atom %q at top level", expr.Line, expr.Atom)
+		}
+		cmds = append(cmds, expr)
+	}
+}
+
+type parser struct {
+	src  []byte
+	pos  int
+	line int
+}
+
+func (p *parser) skipSpace() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == ';' && p.peek(1) == ';':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '(' && p.peek(1) == ';':
+			if err := p.skipBlockComment(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipBlockComment skips a possibly nested `(; ... ;)` comment.
+func (p *parser) skipBlockComment() error {
+	start := p.line
+	depth := 0
+	for p.pos < len(p.src) {
+		switch {
+		case p.src[p.pos] == '(' && p.peek(1) == ';':
+			depth++
+			p.pos += 2
+		case p.src[p.pos] == ';' && p.peek(1) == ')':
+			depth--
+			p.pos += 2
+			if depth == 0 {
+				return nil
+			}
+		default:
+			if p.src[p.pos] == '\n' {
+				p.line++
+			}
+			p.pos++
+		}
+	}
+	return fmt.Errorf("line %d: unterminated block comment", start)
+}
+
+func (p *parser) peek(n int) byte {
+	if p.pos+n < len(p.src) {
+		return p.src[p.pos+n]
+	}
+	return 0
+}
+
+func (p *parser) parse() (*SExpr, error) {
+	line := p.line
+	switch p.src[p.pos] {
+	case '(':
+		p.pos++
+		list := &SExpr{IsList: true, Line: line}
+		for {
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			if p.pos >= len(p.src) {
+				return nil, fmt.Errorf("line %d: unterminated list", line)
+			}
+			if p.src[p.pos] == ')' {
+				p.pos++
+				return list, nil
+			}
+			item, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			list.List = append(list.List, item)
+		}
+	case ')':
+		return nil, fmt.Errorf("line %d: unexpected ')'", line)
+	case '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &SExpr{Atom: s, Quoted: true, Line: line}, nil
+	default:
+		start := p.pos
+		for p.pos < len(p.src) {
+			c := p.src[p.pos]
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')' || c == '"' || c == ';' {
+				break
+			}
+			p.pos++
+		}
+		return &SExpr{Atom: string(p.src[start:p.pos]), Line: line}, nil
+	}
+}
+
+// parseString decodes a string literal. The result holds raw bytes, which is
+// what `(module binary ...)` needs; it is not necessarily valid UTF-8.
+func (p *parser) parseString() (string, error) {
+	line := p.line
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\n':
+			return "", fmt.Errorf("line %d: newline in string", line)
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("line %d: unterminated string", line)
+			}
+			esc := p.src[p.pos]
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '\\', '\'', '"':
+				sb.WriteByte(esc)
+			case 'u':
+				end := strings.IndexByte(string(p.src[p.pos:]), '}')
+				if p.peek(1) != '{' || end < 0 {
+					return "", fmt.Errorf("line %d: malformed unicode escape", line)
+				}
+				r, err := strconv.ParseUint(string(p.src[p.pos+2:p.pos+end]), 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", fmt.Errorf("line %d: malformed unicode escape", line)
+				}
+				sb.WriteRune(rune(r))
+				p.pos += end
+			default:
+				b, err := strconv.ParseUint(string(p.src[p.pos:min(p.pos+2, len(p.src))]), 16, 8)
+				if err != nil {
+					return "", fmt.Errorf("line %d: malformed escape \\%c", line, esc)
+				}
+				sb.WriteByte(byte(b))
+				p.pos++
+			}
+			p.pos++
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated string", line)
+}
@@ -0,0 +1,139 @@
+package spectest
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/luyiming112233/wasm/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// specSkips lists, by script, the lines of the commands TestSpec may skip
+// because they need an interpreter, validator or text format parser.
+var specSkips = map[string][]int{
+	// a text module, invocations of its functions, and assertions on text
+	// modules
+	"binary.wast": {38, 42, 43, 44, 45, 46},
+}
+
+func TestSpec(t *testing.T) {
+	files, err := filepath.Glob("../testdata/spec/*.wast")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			skips := specSkips[filepath.Base(file)]
+			res, err := RunFile(file, Options{SkipLines: skips})
+			require.NoError(t, err)
+			for _, failure := range res.Failures {
+				t.Error(failure)
+			}
+			// a command checked after all must come off the list
+			assert.Equal(t, len(skips), res.Skipped, "skipped commands")
+			t.Logf("passed %d, failed %d, skipped %d", res.Passed, res.Failed, res.Skipped)
+		})
+	}
+}
+
+func TestRunSkips(t *testing.T) {
+	src := []byte(`(assert_return (invoke "f") (i32.const 1))`)
+	res, err := Run(src, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Failed)
+	assert.Contains(t, res.Failures[0], "not checked")
+
+	res, err = Run(src, Options{AllowSkips: true})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Skipped: 1}, *res)
+
+	res, err = Run(append([]byte("\n"), src...), Options{SkipLines: []int{1}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Failed, "only the listed line may be skipped")
+	res, err = Run(src, Options{SkipLines: []int{1}})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Skipped: 1}, *res)
+}
+
+func TestAssertInvalid(t *testing.T) {
+	res, err := Run([]byte(`
+(assert_invalid
+  (module binary "\00asm" "\01\00\00\00" "\04\05\01\64\70\00\00")
+  "type mismatch")
+(assert_invalid (module binary "\00asm" "\01\00\00\00" "\04\01") "type mismatch")
+(assert_invalid (module binary "\00asm" "\01\00\00\00") "type mismatch")
+`), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Passed) // a non-nullable table without an init expression
+	if assert.Len(t, res.Failures, 2) {
+		assert.Contains(t, res.Failures[0], "module is malformed")
+		assert.Contains(t, res.Failures[1], "not checked")
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	for _, c := range []struct {
+		src string
+		exp Value
+	}{
+		{src: "(i32.const -1)", exp: Value{Type: common.ValTypeI32, Bits: 0xFFFFFFFF}},
+		{src: "(i32.const 0xffff_ffff)", exp: Value{Type: common.ValTypeI32, Bits: 0xFFFFFFFF}},
+		{src: "(i64.const -0x8000000000000000)", exp: Value{Type: common.ValTypeI64, Bits: 1 << 63}},
+		{src: "(f32.const -0x1.8p1)", exp: Value{Type: common.ValTypeF32, Bits: uint64(math.Float32bits(-3))}},
+		{src: "(f32.const 0x1p-149)", exp: Value{Type: common.ValTypeF32, Bits: 1}},
+		{src: "(f64.const -inf)", exp: Value{Type: common.ValTypeF64, Bits: math.Float64bits(math.Inf(-1))}},
+		{src: "(f64.const nan:0x4)", exp: Value{Type: common.ValTypeF64, Bits: 0x7FF0000000000004}},
+		{src: "(f32.const nan:arithmetic)", exp: Value{Type: common.ValTypeF32, NaN: NaNArithmetic}},
+		{src: "(ref.null extern)", exp: Value{Type: common.ValTypeExternRef}},
+		{src: "(ref.extern 0)", exp: Value{Type: common.ValTypeExternRef, Bits: 1}},
+		{src: "(ref.func)", exp: Value{Type: common.ValTypeFuncRef, NonNull: true}},
+	} {
+		cmds, err := ParseScript([]byte(c.src))
+		require.NoError(t, err)
+		actual, err := parseValue(cmds[0], true)
+		require.NoError(t, err, c.src)
+		assert.Equal(t, c.exp, actual, c.src)
+	}
+}
+
+func TestValueMatch(t *testing.T) {
+	canonical := Value{Type: common.ValTypeF32, NaN: NaNCanonical}
+	assert.True(t, canonical.Match(0x7FC00000))
+	assert.True(t, canonical.Match(0xFFC00000))
+	assert.False(t, canonical.Match(0x7FC00001))
+
+	arithmetic := Value{Type: common.ValTypeF64, NaN: NaNArithmetic}
+	assert.True(t, arithmetic.Match(0x7FF8000000000001))
+	assert.False(t, arithmetic.Match(0x7FF0000000000001))
+	assert.False(t, arithmetic.Match(math.Float64bits(1)))
+
+	nonNull := Value{Type: common.ValTypeFuncRef, NonNull: true}
+	assert.True(t, nonNull.Match(3))
+	assert.False(t, nonNull.Match(0))
+
+	exact := Value{Type: common.ValTypeI32, Bits: 0xFFFFFFFF}
+	assert.True(t, exact.Match(0xFFFFFFFF))
+}
+
+func TestParseV128(t *testing.T) {
+	cmds, err := ParseScript([]byte("(v128.const i16x8 -1 0 0 0 0 0 0 1)"))
+	require.NoError(t, err)
+	v, err := parseValue(cmds[0], false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0xFFFF), v.Bits)
+	assert.Equal(t, uint64(1)<<48, v.Hi)
+
+	cmds, err = ParseScript([]byte("(v128.const f32x4 nan:canonical 1 2 3)"))
+	require.NoError(t, err)
+	v, err = parseValue(cmds[0], true)
+	require.NoError(t, err)
+	if assert.Len(t, v.Lanes, 4) {
+		assert.Equal(t, NaNCanonical, v.Lanes[0].NaN)
+		assert.Equal(t, uint64(math.Float32bits(3)), v.Lanes[3].Bits)
+	}
+
+	_, err = parseValue(cmds[0], false)
+	assert.Error(t, err)
+}
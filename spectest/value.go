@@ -0,0 +1,359 @@
+package spectest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/luyiming112233/wasm/common"
+)
+
+// NaNPattern describes what an assert_return accepts for a float result.
+type NaNPattern byte
+
+const (
+	NaNNone       NaNPattern = iota // exact bit pattern
+	NaNCanonical                    // nan:canonical
+	NaNArithmetic                   // nan:arithmetic
+)
+
+// Value is an argument or expected result in a script. Bits holds the value
+// the way an operand stack slot does: integers zero-extended, floats as their
+// IEEE 754 bits, references as 0 for null and the host value plus one for
+// `(ref.extern n)`, so that `(ref.extern 0)` is not null. A v128 keeps its
+// high 64 bits in Hi and its lanes, each an i32, i64, f32 or f64 value, in
+// Lanes.
+type Value struct {
+	Type  common.ValType
+	Bits  uint64
+	Hi    uint64
+	NaN   NaNPattern
+	Lanes []Value
+	// NonNull is set for an expected `(ref.func)` or `(ref.extern)`
+	// without a value, which any non-null reference matches
+	NonNull bool
+}
+
+const (
+	f32CanonicalNaN = 0x7FC00000
+	f32QuietBit     = 0x00400000
+	f64CanonicalNaN = 0x7FF8000000000000
+	f64QuietBit     = 0x0008000000000000
+)
+
+// Match reports whether the result bits produced by the engine satisfy v.
+func (v Value) Match(bits uint64) bool {
+	switch v.Type {
+	case common.ValTypeF32:
+		bits = uint64(uint32(bits))
+		switch v.NaN {
+		case NaNCanonical:
+			return uint32(bits)&^(1<<31) == f32CanonicalNaN
+		case NaNArithmetic:
+			return uint32(bits)&f32CanonicalNaN == f32CanonicalNaN && uint32(bits)&f32QuietBit != 0
+		}
+		return bits == v.Bits
+	case common.ValTypeF64:
+		switch v.NaN {
+		case NaNCanonical:
+			return bits&^(1<<63) == f64CanonicalNaN
+		case NaNArithmetic:
+			return bits&f64CanonicalNaN == f64CanonicalNaN && bits&f64QuietBit != 0
+		}
+		return bits == v.Bits
+	case common.ValTypeI32:
+		return uint32(bits) == uint32(v.Bits)
+	case common.ValTypeFuncRef, common.ValTypeExternRef:
+		if v.NonNull {
+			return bits != 0
+		}
+		return bits == v.Bits
+	default:
+		return bits == v.Bits
+	}
+}
+
+func (v Value) String() string {
+	switch v.NaN {
+	case NaNCanonical:
+		return "nan:canonical"
+	case NaNArithmetic:
+		return "nan:arithmetic"
+	}
+	if v.NonNull {
+		if v.Type == common.ValTypeFuncRef {
+			return "ref.func"
+		}
+		return "ref.extern"
+	}
+	switch v.Type {
+	case common.ValTypeV128:
+		lanes := make([]string, 0, len(v.Lanes))
+		for _, lane := range v.Lanes {
+			lanes = append(lanes, lane.String())
+		}
+		return "v128:[" + strings.Join(lanes, " ") + "]"
+	case common.ValTypeI32:
+		return fmt.Sprintf("i32:%d", int32(v.Bits))
+	case common.ValTypeI64:
+		return fmt.Sprintf("i64:%d", int64(v.Bits))
+	case common.ValTypeF32:
+		return fmt.Sprintf("f32:%v", math.Float32frombits(uint32(v.Bits)))
+	case common.ValTypeF64:
+		return fmt.Sprintf("f64:%v", math.Float64frombits(v.Bits))
+	case common.ValTypeFuncRef, common.ValTypeExternRef:
+		if v.Bits == 0 {
+			return "ref.null"
+		}
+		if v.Type == common.ValTypeExternRef {
+			return fmt.Sprintf("ref.extern:%d", v.Bits-1)
+		}
+		return fmt.Sprintf("ref.func:%d", v.Bits)
+	default:
+		return fmt.Sprintf("0x%x", v.Bits)
+	}
+}
+
+// parseValue parses a constant such as `(i32.const -1)` or
+// `(f32.const nan:arithmetic)`. NaN patterns are only allowed when
+// pattern is set, i.e. for expected results.
+func parseValue(e *SExpr, pattern bool) (Value, error) {
+	switch e.Head() {
+	case "ref.func", "ref.extern", "ref.null":
+		return parseRef(e, pattern)
+	case "v128.const":
+		return parseV128(e, pattern)
+	}
+	if len(e.List) != 2 || e.List[1].IsList {
+		return Value{}, fmt.Errorf("line %d: malformed constant %s", e.Line, e)
+	}
+	lit := e.List[1].Atom
+	switch e.Head() {
+	case "i32.const":
+		n, err := parseInt(lit, 32)
+		return Value{Type: common.ValTypeI32, Bits: n}, err
+	case "i64.const":
+		n, err := parseInt(lit, 64)
+		return Value{Type: common.ValTypeI64, Bits: n}, err
+	case "f32.const":
+		if p, ok := parseNaNPattern(lit); ok {
+			if !pattern {
+				return Value{}, fmt.Errorf("line %d: %s is only allowed in results", e.Line, lit)
+			}
+			return Value{Type: common.ValTypeF32, NaN: p}, nil
+		}
+		bits, err := parseFloat(lit, 32)
+		return Value{Type: common.ValTypeF32, Bits: bits}, err
+	case "f64.const":
+		if p, ok := parseNaNPattern(lit); ok {
+			if !pattern {
+				return Value{}, fmt.Errorf("line %d: %s is only allowed in results", e.Line, lit)
+			}
+			return Value{Type: common.ValTypeF64, NaN: p}, nil
+		}
+		bits, err := parseFloat(lit, 64)
+		return Value{Type: common.ValTypeF64, Bits: bits}, err
+	default:
+		return Value{}, fmt.Errorf("line %d: unsupported constant %s", e.Line, e)
+	}
+}
+
+// parseRef parses `(ref.null func|extern)`, `(ref.extern n)` and, in
+// results only, `(ref.func)` and `(ref.extern)` standing for any non-null
+// reference.
+func parseRef(e *SExpr, pattern bool) (Value, error) {
+	refType := common.ValTypeExternRef
+	if e.Head() == "ref.func" {
+		refType = common.ValTypeFuncRef
+	}
+	if len(e.List) > 2 || len(e.List) == 2 && e.List[1].IsList {
+		return Value{}, fmt.Errorf("line %d: malformed constant %s", e.Line, e)
+	}
+
+	if e.Head() == "ref.null" {
+		if len(e.List) != 2 {
+			return Value{}, fmt.Errorf("line %d: malformed constant %s", e.Line, e)
+		}
+		switch e.List[1].Atom {
+		case "func", "funcref":
+			return Value{Type: common.ValTypeFuncRef}, nil
+		case "extern", "externref":
+			return Value{Type: common.ValTypeExternRef}, nil
+		default:
+			return Value{}, fmt.Errorf("line %d: unknown heap type %s", e.Line, e.List[1].Atom)
+		}
+	}
+	if len(e.List) == 1 {
+		if !pattern {
+			return Value{}, fmt.Errorf("line %d: %s without a value is only allowed in results", e.Line, e.Head())
+		}
+		return Value{Type: refType, NonNull: true}, nil
+	}
+	if refType == common.ValTypeFuncRef {
+		return Value{}, fmt.Errorf("line %d: unsupported constant %s", e.Line, e)
+	}
+
+	n, err := parseInt(e.List[1].Atom, 32)
+	return Value{Type: refType, Bits: n + 1}, err
+}
+
+// v128Shapes maps a v128.const shape to its lane width and lane type.
+var v128Shapes = map[string]struct {
+	bits int
+	typ  common.ValType
+}{
+	"i8x16": {8, common.ValTypeI32},
+	"i16x8": {16, common.ValTypeI32},
+	"i32x4": {32, common.ValTypeI32},
+	"i64x2": {64, common.ValTypeI64},
+	"f32x4": {32, common.ValTypeF32},
+	"f64x2": {64, common.ValTypeF64},
+}
+
+// parseV128 parses `(v128.const shape lane*)`. Float lanes of expected
+// results may be NaN patterns.
+func parseV128(e *SExpr, pattern bool) (Value, error) {
+	if len(e.List) < 2 || e.List[1].IsList {
+		return Value{}, fmt.Errorf("line %d: malformed constant %s", e.Line, e)
+	}
+	shape, ok := v128Shapes[e.List[1].Atom]
+	if !ok {
+		return Value{}, fmt.Errorf("line %d: unknown v128 shape %s", e.Line, e.List[1].Atom)
+	}
+	lits := e.List[2:]
+	if len(lits) != 128/shape.bits {
+		return Value{}, fmt.Errorf("line %d: %s needs %d lanes, got %d", e.Line, e.List[1].Atom, 128/shape.bits, len(lits))
+	}
+
+	v := Value{Type: common.ValTypeV128, Lanes: make([]Value, 0, len(lits))}
+	for i, lit := range lits {
+		if lit.IsList {
+			return Value{}, fmt.Errorf("line %d: malformed constant %s", e.Line, e)
+		}
+		lane := Value{Type: shape.typ}
+		var err error
+		switch shape.typ {
+		case common.ValTypeF32, common.ValTypeF64:
+			if p, ok := parseNaNPattern(lit.Atom); ok {
+				if !pattern {
+					return Value{}, fmt.Errorf("line %d: %s is only allowed in results", e.Line, lit.Atom)
+				}
+				lane.NaN = p
+				break
+			}
+			lane.Bits, err = parseFloat(lit.Atom, shape.bits)
+		default:
+			lane.Bits, err = parseInt(lit.Atom, shape.bits)
+			if shape.bits < 32 {
+				lane.Bits &= 1<<shape.bits - 1
+			}
+		}
+		if err != nil {
+			return Value{}, fmt.Errorf("line %d: %w", e.Line, err)
+		}
+
+		shift := i * shape.bits
+		if shift < 64 {
+			v.Bits |= lane.Bits << shift
+		} else {
+			v.Hi |= lane.Bits << (shift - 64)
+		}
+		v.Lanes = append(v.Lanes, lane)
+	}
+	return v, nil
+}
+
+func parseNaNPattern(lit string) (NaNPattern, bool) {
+	switch lit {
+	case "nan:canonical":
+		return NaNCanonical, true
+	case "nan:arithmetic":
+		return NaNArithmetic, true
+	default:
+		return NaNNone, false
+	}
+}
+
+// parseInt accepts both the signed and unsigned spelling of an integer of
+// the given width and returns it zero-extended.
+func parseInt(lit string, bitSize int) (uint64, error) {
+	lit = strings.ReplaceAll(lit, "_", "")
+	neg := strings.HasPrefix(lit, "-")
+	lit = strings.TrimLeft(lit, "+-")
+
+	n, err := strconv.ParseUint(lit, 0, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid i%d literal %q: %w", bitSize, lit, err)
+	}
+	if neg {
+		if n > 1<<(bitSize-1) {
+			return 0, fmt.Errorf("invalid i%d literal -%s: out of range", bitSize, lit)
+		}
+		n = -n
+	}
+	if bitSize == 32 {
+		n = uint64(uint32(n))
+	}
+	return n, nil
+}
+
+// parseFloat parses the text format's float syntax, including hex floats,
+// `inf` and `nan:0x...` payloads, into IEEE 754 bits.
+func parseFloat(lit string, bitSize int) (uint64, error) {
+	lit = strings.ReplaceAll(lit, "_", "")
+	neg := strings.HasPrefix(lit, "-")
+	mag := strings.TrimLeft(lit, "+-")
+
+	var bits uint64
+	switch {
+	case mag == "inf":
+		bits = math.Float64bits(math.Inf(1))
+		if bitSize == 32 {
+			bits = uint64(math.Float32bits(float32(math.Inf(1))))
+		}
+	case mag == "nan":
+		bits = f64CanonicalNaN
+		if bitSize == 32 {
+			bits = f32CanonicalNaN
+		}
+	case strings.HasPrefix(mag, "nan:0x"):
+		payload, err := strconv.ParseUint(mag[len("nan:0x"):], 16, 64)
+		if err != nil || payload == 0 {
+			return 0, fmt.Errorf("invalid nan payload %q", lit)
+		}
+		if bitSize == 32 {
+			if payload >= 1<<23 {
+				return 0, fmt.Errorf("nan payload %q out of range", lit)
+			}
+			bits = 0x7F800000 | payload
+		} else {
+			if payload >= 1<<52 {
+				return 0, fmt.Errorf("nan payload %q out of range", lit)
+			}
+			bits = 0x7FF0000000000000 | payload
+		}
+	default:
+		if strings.HasPrefix(mag, "0x") && !strings.ContainsAny(mag, "pP") {
+			mag += "p0"
+		}
+		f, err := strconv.ParseFloat(mag, bitSize)
+		if err != nil {
+			return 0, fmt.Errorf("invalid f%d literal %q: %w", bitSize, lit, err)
+		}
+		if bitSize == 32 {
+			bits = uint64(math.Float32bits(float32(f)))
+		} else {
+			bits = math.Float64bits(f)
+		}
+	}
+
+	if neg {
+		if bitSize == 32 {
+			bits |= 1 << 31
+		} else {
+			bits |= 1 << 63
+		}
+	}
+	return bits, nil
+}
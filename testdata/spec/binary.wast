@@ -0,0 +1,46 @@
+;; Binary format checks runnable by the decoder alone. Drop the official
+;; testsuite's .wast files next to this one to run them offline.
+
+(module binary "\00asm" "\01\00\00\00")
+(module $M1 binary "\00asm" "\01\00\00\00")
+(register "M1" $M1)
+
+;; type section with one function type (i32) -> ()
+(module binary
+  "\00asm" "\01\00\00\00"
+  "\01\05\01"            ;; type section
+  "\60\01\7f\00"         ;; (func (param i32))
+)
+
+(; unknown sections (; nested comment ;) ;)
+(assert_malformed (module binary "") "unexpected end")
+(assert_malformed (module binary "\00asm") "unexpected end")
+(assert_malformed (module binary "\00asm" "\02\00\00\00") "unknown binary version")
+(assert_malformed (module binary "asm\00" "\01\00\00\00") "magic header not detected")
+(assert_malformed
+  (module binary "\00asm" "\01\00\00\00" "\7f\00")
+  "malformed section id"
+)
+(assert_malformed
+  (module binary "\00asm" "\01\00\00\00" "\01\02\00")
+  "section size mismatch"
+)
+(assert_malformed
+  (module binary "\00asm" "\01\00\00\00" "\03\01\00" "\03\01\00")
+  "unexpected content after last section"
+)
+(assert_malformed
+  (module binary "\00asm" "\01\00\00\00" "\00\02\7f\61")
+  "length out of bounds"
+)
+
+;; text modules and actions need stages that do not exist yet
+(module
+  (func (export "nan") (result f32) (f32.const nan))
+  (func (export "add") (param i64 i64) (result i64) (i64.add (local.get 0) (local.get 1)))
+)
+(assert_return (invoke "nan") (f32.const nan:canonical))
+(assert_return (invoke "add" (i64.const 0x7fff_ffff_ffff_ffff) (i64.const 1)) (i64.const -0x8000_0000_0000_0000))
+(assert_trap (invoke "div" (i32.const 1) (i32.const 0)) "integer divide by zero")
+(assert_malformed (module quote "(func") "unexpected end")
+(assert_invalid (module (func (result i32))) "type mismatch")